@@ -1,54 +1,203 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/config"
 	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/events"
 	"github.com/MSTimX/Snowops-roles/internal/handlers"
+	"github.com/MSTimX/Snowops-roles/internal/health"
+	"github.com/MSTimX/Snowops-roles/internal/logging"
+	"github.com/MSTimX/Snowops-roles/internal/metrics"
 	"github.com/MSTimX/Snowops-roles/internal/middleware"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/openapi"
+	"github.com/MSTimX/Snowops-roles/internal/pass"
+	"github.com/MSTimX/Snowops-roles/internal/tracing"
+	"github.com/MSTimX/Snowops-roles/internal/webhooks"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("warning: failed to load .env file: %v", err)
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Init(slog.LevelInfo)
+		logging.Fatal("некорректная конфигурация", "error", err)
+	}
+	level, _ := cfg.Log.SlogLevel()
+	logging.Init(level)
+	slog.Info("конфигурация загружена", cfg.Redacted()...)
+	if cfg.Auth.Mode == config.AuthModeMock {
+		printMockAuthBanner()
+	}
+	// Часовой пояс проверен при загрузке конфигурации.
+	location, err := time.LoadLocation(cfg.Database.TimeZone)
+	if err != nil {
+		logging.Fatal("некорректный часовой пояс", "timezone", cfg.Database.TimeZone, "error", err)
+	}
+	models.Location = location
+	if cfg.Env == config.EnvDevelopment {
+		// SMS-шлюза нет: при разработке код подтверждения выводится в консоль.
+		handlers.SendVerificationCode = handlers.PrintVerificationCode
+	}
+
+	// Первый SIGINT/SIGTERM запускает плавную остановку; повторный завершает процесс сразу.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing.Exporter)
+	if err != nil {
+		logging.Fatal("не удалось настроить трассировку", "error", err)
 	}
 
-	database.Init()
+	if err := database.Init(ctx, cfg.Database); err != nil {
+		logging.Fatal("не удалось подключиться к базе данных", "error", err)
+	}
 	database.Migrate()
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "8080"
+	if err := tracing.InstrumentDB(database.DB); err != nil {
+		logging.Fatal("не удалось подключить трассировку базы данных", "error", err)
+	}
+	if err := metrics.RegisterDB(database.DB); err != nil {
+		logging.Fatal("не удалось подключить метрики базы данных", "error", err)
+	}
+	if err := metrics.RegisterBusiness(database.DB); err != nil {
+		logging.Fatal("не удалось подключить бизнес-метрики", "error", err)
 	}
+	pass.Init(cfg.Pass.SigningKey, cfg.Env == config.EnvDevelopment)
 
-	address := port
-	if !strings.Contains(port, ":") {
-		address = ":" + port
+	// Доставка доменных событий из outbox и отправка webhook работают в фоне
+	// до начала остановки сервиса.
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		events.NewDispatcher(database.DB, webhooks.FanOut{DB: database.DB}).Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		webhooks.NewSender(database.DB).Run(workersCtx)
+	}()
+
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
 	}
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(logging.Middleware())
+	router.Use(middleware.RecoveryMiddleware())
+	router.Use(metrics.GinMiddleware())
+
+	readiness := health.NewChecker()
+	readiness.Add("database", database.Ping)
+	readiness.Add("schema", database.CheckSchema)
+	readiness.Add("pass_signing_key", func(context.Context) error { return pass.Ready() })
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// /health оставлен для совместимости и равнозначен /livez.
+	router.GET("/health", health.Livez)
+	router.GET("/livez", health.Livez)
+	router.GET("/readyz", readiness.Readyz)
+	router.GET("/openapi.json", openapi.Handler)
+	router.GET("/metrics", metrics.Handler())
 
-	authMode := os.Getenv("AUTH_MODE")
+	handlers.RegisterPublicRoutes(router.Group("/api/v1/public"))
 
 	api := router.Group("/api/v1")
-	if strings.ToLower(authMode) == "jwt" {
-		api.Use(middleware.JWTAuthMiddleware())
-	} else {
+	switch cfg.Auth.Mode {
+	case config.AuthModeJWT:
+		resolver := auth.NewResolver(database.DB, cfg.Auth.IdentityCacheTTL)
+		api.Use(middleware.JWTAuthMiddleware(cfg.Auth.JWTSecret, resolver))
+		handlers.InvalidateAPIKey = resolver.InvalidateAPIKey
+	case config.AuthModeMock:
+		// Конфигурация допускает mock только при APP_ENV=development.
 		api.Use(middleware.MockAuthMiddleware())
 	}
 	handlers.RegisterRoutes(api)
 
-	log.Printf("starting server on port %s", port)
-	log.Println("App started")
+	server := &http.Server{
+		Addr:              cfg.HTTP.Address(),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	shutdownTimeout := cfg.HTTP.ShutdownTimeout
 
-	if err := router.Run(address); err != nil {
-		log.Fatalf("server exited with error: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("сервис запущен", "address", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Info("получен сигнал остановки, завершаем обработку запросов", "timeout", shutdownTimeout.String())
+	case err := <-serverErr:
+		slog.Error("сервер завершился с ошибкой", "error", err)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Новые запросы не принимаются, начатые (в том числе транзакции) дорабатывают
+	// до истечения SHUTDOWN_TIMEOUT.
+	readiness.SetDraining()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("не все запросы завершились до истечения таймаута", "error", err)
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		slog.Error("фоновые обработчики не остановились до истечения таймаута")
 	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("не удалось завершить трассировку", "error", err)
+	}
+	if err := database.Close(); err != nil {
+		slog.Error("не удалось закрыть подключения к базе данных", "error", err)
+	}
+	slog.Info("сервис остановлен")
+}
+
+// printMockAuthBanner предупреждает, что любой клиент может назначить себе
+// произвольную роль заголовками. Баннер пишется и в stderr, чтобы его было
+// видно при локальном запуске, и в журнал.
+func printMockAuthBanner() {
+	const banner = `
+################################################################
+#  ВНИМАНИЕ: AUTH_MODE=mock — аутентификация ОТКЛЮЧЕНА.         #
+#  Роль и организация берутся из заголовков X-User-ID,          #
+#  X-User-Role и X-Org-ID без проверки. Только для разработки.  #
+################################################################
+`
+	fmt.Fprint(os.Stderr, banner)
+	slog.Warn("аутентификация отключена: включён режим mock, роли берутся из заголовков запроса",
+		"auth_mode", config.AuthModeMock,
+	)
 }
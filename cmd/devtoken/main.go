@@ -0,0 +1,46 @@
+// Команда devtoken выпускает JWT для локальной разработки тем же секретом,
+// что использует сервис (JWT_SECRET из окружения, .env или CONFIG_FILE).
+//
+//	go run ./cmd/devtoken -user <uuid> -role AKIMAT_ADMIN -org <uuid>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MSTimX/Snowops-roles/internal/authtest"
+	"github.com/MSTimX/Snowops-roles/internal/config"
+)
+
+func main() {
+	var identity authtest.Identity
+	flag.StringVar(&identity.UserID, "user", "", "идентификатор пользователя")
+	flag.StringVar(&identity.Role, "role", "", "роль пользователя")
+	flag.StringVar(&identity.OrganizationID, "org", "", "идентификатор организации")
+	ttl := flag.Duration("ttl", authtest.DefaultTTL, "срок действия токена")
+	flag.Parse()
+
+	if identity.UserID == "" || identity.Role == "" || identity.OrganizationID == "" {
+		fmt.Fprintln(os.Stderr, "нужно указать -user, -role и -org")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "некорректная конфигурация: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Env != config.EnvDevelopment {
+		fmt.Fprintf(os.Stderr, "devtoken работает только при APP_ENV=%s\n", config.EnvDevelopment)
+		os.Exit(1)
+	}
+
+	token, err := authtest.MintToken(cfg.Auth.JWTSecret, identity, *ttl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "не удалось выпустить токен: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,303 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+)
+
+// ContextKey — ключ контекста gin, под которым Respond сохраняет код ошибки ответа.
+const ContextKey = "errorCode"
+
+// Code — стабильный машинный код ошибки, на который опираются клиенты.
+type Code string
+
+// Коды ошибок API.
+const (
+	CodeUnauthorized            Code = "UNAUTHORIZED"
+	CodeMissingToken            Code = "MISSING_TOKEN"
+	CodeInvalidToken            Code = "INVALID_TOKEN"
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeForbiddenScope          Code = "FORBIDDEN_SCOPE"
+	CodeValidationFailed        Code = "VALIDATION_FAILED"
+	CodeInvalidID               Code = "INVALID_ID"
+	CodeUnsupportedOrgType      Code = "UNSUPPORTED_ORG_TYPE"
+	CodeOrgNotFound             Code = "ORG_NOT_FOUND"
+	CodeUserNotFound            Code = "USER_NOT_FOUND"
+	CodeDriverNotFound          Code = "DRIVER_NOT_FOUND"
+	CodeDocumentNotFound        Code = "DOCUMENT_NOT_FOUND"
+	CodeVehicleNotFound         Code = "VEHICLE_NOT_FOUND"
+	CodeDriverNotInContractor   Code = "DRIVER_NOT_IN_CONTRACTOR"
+	CodeDuplicateEntity         Code = "DUPLICATE_ENTITY"
+	CodeDriverRehireRequired    Code = "DRIVER_REHIRE_REQUIRED"
+	CodeDriverAlreadyActive     Code = "DRIVER_ALREADY_ACTIVE"
+	CodeNotImplemented          Code = "NOT_IMPLEMENTED"
+	CodeInternal                Code = "INTERNAL_ERROR"
+	CodeDatabaseNotReady        Code = "DATABASE_NOT_READY"
+	CodeAuthMisconfigured       Code = "AUTH_MISCONFIGURED"
+	CodeAccountDisabled         Code = "ACCOUNT_DISABLED"
+	CodeInvalidCurrentOrgID     Code = "INVALID_CURRENT_ORG_ID"
+	CodeMissingQueryParameter   Code = "MISSING_QUERY_PARAMETER"
+	CodeContractNotFound        Code = "CONTRACT_NOT_FOUND"
+	CodeContractRequired        Code = "CONTRACT_REQUIRED"
+	CodeContractVehicleLimit    Code = "CONTRACT_VEHICLE_LIMIT"
+	CodeAreaNotFound            Code = "SERVICE_AREA_NOT_FOUND"
+	CodeAreaOutsideParent       Code = "SERVICE_AREA_OUTSIDE_PARENT"
+	CodeAreaHasChildren         Code = "SERVICE_AREA_HAS_CHILDREN"
+	CodeShiftNotFound           Code = "SHIFT_NOT_FOUND"
+	CodeShiftConflict           Code = "SHIFT_CONFLICT"
+	CodeShiftNotSchedulable     Code = "SHIFT_NOT_SCHEDULABLE"
+	CodeVerificationNotFound    Code = "VERIFICATION_NOT_FOUND"
+	CodeVerificationFailed      Code = "VERIFICATION_FAILED"
+	CodePassNotIssuable         Code = "PASS_NOT_ISSUABLE"
+	CodeWebhookNotFound         Code = "WEBHOOK_NOT_FOUND"
+	CodeWebhookDeliveryNotFound Code = "WEBHOOK_DELIVERY_NOT_FOUND"
+	CodeServiceAccountNotFound  Code = "SERVICE_ACCOUNT_NOT_FOUND"
+	CodeAPIKeyNotFound          Code = "API_KEY_NOT_FOUND"
+)
+
+type codeInfo struct {
+	status  int
+	message string
+}
+
+var codes = map[Code]codeInfo{
+	CodeUnauthorized:            {http.StatusUnauthorized, "unauthorized"},
+	CodeMissingToken:            {http.StatusUnauthorized, "missing token"},
+	CodeInvalidToken:            {http.StatusUnauthorized, "invalid token"},
+	CodeForbidden:               {http.StatusForbidden, "forbidden"},
+	CodeForbiddenScope:          {http.StatusForbidden, "resource is outside of your organization scope"},
+	CodeValidationFailed:        {http.StatusBadRequest, "request validation failed"},
+	CodeInvalidID:               {http.StatusBadRequest, "invalid identifier"},
+	CodeUnsupportedOrgType:      {http.StatusBadRequest, "unsupported organization type"},
+	CodeOrgNotFound:             {http.StatusNotFound, "organization not found"},
+	CodeUserNotFound:            {http.StatusNotFound, "user not found"},
+	CodeDriverNotFound:          {http.StatusNotFound, "driver not found"},
+	CodeDocumentNotFound:        {http.StatusNotFound, "document not found"},
+	CodeVehicleNotFound:         {http.StatusNotFound, "vehicle not found"},
+	CodeDriverNotInContractor:   {http.StatusUnprocessableEntity, "driver does not belong to the vehicle's contractor"},
+	CodeDuplicateEntity:         {http.StatusConflict, "entity with the same unique attributes already exists"},
+	CodeDriverRehireRequired:    {http.StatusConflict, "driver with this iin is inactive, rehire it instead"},
+	CodeDriverAlreadyActive:     {http.StatusConflict, "driver is already active"},
+	CodeNotImplemented:          {http.StatusNotImplemented, "not implemented yet"},
+	CodeInternal:                {http.StatusInternalServerError, "internal server error"},
+	CodeDatabaseNotReady:        {http.StatusServiceUnavailable, "database not initialized"},
+	CodeAuthMisconfigured:       {http.StatusInternalServerError, "authentication is not configured"},
+	CodeAccountDisabled:         {http.StatusUnauthorized, "user account or organization is disabled"},
+	CodeInvalidCurrentOrgID:     {http.StatusBadRequest, "invalid current organization id"},
+	CodeMissingQueryParameter:   {http.StatusBadRequest, "required query parameter is missing"},
+	CodeContractNotFound:        {http.StatusNotFound, "contract not found"},
+	CodeContractRequired:        {http.StatusForbidden, "organization has no contract in force"},
+	CodeContractVehicleLimit:    {http.StatusUnprocessableEntity, "contract vehicle limit reached"},
+	CodeAreaNotFound:            {http.StatusNotFound, "service area not found"},
+	CodeAreaOutsideParent:       {http.StatusUnprocessableEntity, "contractor service area must lie inside the parent TOO's areas"},
+	CodeAreaHasChildren:         {http.StatusConflict, "service area has active contractor areas"},
+	CodeShiftNotFound:           {http.StatusNotFound, "shift not found"},
+	CodeShiftConflict:           {http.StatusConflict, "driver or vehicle is already booked for this period"},
+	CodeShiftNotSchedulable:     {http.StatusUnprocessableEntity, "driver or vehicle cannot be scheduled"},
+	CodeVerificationNotFound:    {http.StatusNotFound, "no pending phone verification"},
+	CodeVerificationFailed:      {http.StatusUnprocessableEntity, "verification code is invalid or expired"},
+	CodePassNotIssuable:         {http.StatusUnprocessableEntity, "pass cannot be issued for this driver and vehicle"},
+	CodeWebhookNotFound:         {http.StatusNotFound, "webhook not found"},
+	CodeWebhookDeliveryNotFound: {http.StatusNotFound, "webhook delivery not found"},
+	CodeServiceAccountNotFound:  {http.StatusNotFound, "service account not found"},
+	CodeAPIKeyNotFound:          {http.StatusNotFound, "API key not found"},
+}
+
+// FieldError описывает ошибку проверки одного поля запроса.
+// Label и Message заполняются при ответе на языке клиента.
+type FieldError struct {
+	Field   string `json:"field"`
+	Label   string `json:"label"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// FieldRequired описывает отсутствующее обязательное поле.
+func FieldRequired(field string) FieldError {
+	return FieldError{Field: field, Rule: "required"}
+}
+
+// Error — типизированная ошибка API с кодом, HTTP-статусом и деталями.
+// Message хранит английский текст, который при ответе переводится на язык клиента.
+type Error struct {
+	Code    Code
+	Message string
+	Args    []interface{}
+	Details []FieldError
+	Meta    map[string]interface{}
+
+	// cause и context пишутся в журнал и не попадают в ответ клиенту.
+	cause   error
+	context string
+}
+
+// New создаёт ошибку с сообщением по умолчанию для кода.
+func New(code Code) *Error {
+	return &Error{Code: code, Message: codes[code].message}
+}
+
+// Internal создаёт ошибку INTERNAL_ERROR, сохраняя причину только для журнала.
+func Internal(context string, cause error) *Error {
+	e := New(CodeInternal)
+	e.context = context
+	e.cause = cause
+	return e
+}
+
+// Error реализует интерфейс error.
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.context, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, i18n.T(i18n.LangEN, e.Message, e.Args...))
+}
+
+// Unwrap возвращает исходную причину ошибки.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Status возвращает HTTP-статус, соответствующий коду ошибки.
+func (e *Error) Status() int {
+	if info, ok := codes[e.Code]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// WithMessage заменяет сообщение по умолчанию. Сообщение должно присутствовать
+// в каталогах i18n; аргументы типа i18n.Label переводятся как подписи полей.
+func (e *Error) WithMessage(message string, args ...interface{}) *Error {
+	e.Message = message
+	e.Args = args
+	return e
+}
+
+// WithDetails добавляет ошибки проверки полей.
+func (e *Error) WithDetails(details ...FieldError) *Error {
+	e.Details = append(e.Details, details...)
+	return e
+}
+
+// With добавляет в ответ дополнительное поле.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Meta == nil {
+		e.Meta = make(map[string]interface{})
+	}
+	e.Meta[key] = value
+	return e
+}
+
+// Respond прерывает обработку запроса и отправляет ошибку в едином формате.
+func Respond(c *gin.Context, err *Error) {
+	if err.cause != nil || err.Status() >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "запрос завершился ошибкой",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"error", err,
+		)
+	}
+
+	c.Set(ContextKey, err.Code)
+
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", string(lang))
+
+	body := gin.H{
+		"code":    err.Code,
+		"message": i18n.T(lang, err.Message, err.Args...),
+	}
+	if len(err.Details) > 0 {
+		details := make([]FieldError, len(err.Details))
+		for i, detail := range err.Details {
+			detail.Label = i18n.LabelFor(lang, detail.Field)
+			detail.Message = fieldMessage(lang, detail.Field, detail.Rule, detail.Param)
+			details[i] = detail
+		}
+		body["details"] = details
+	}
+	if requestID := c.GetString("requestID"); requestID != "" {
+		body["request_id"] = requestID
+	}
+	for key, value := range err.Meta {
+		body[key] = value
+	}
+
+	c.AbortWithStatusJSON(err.Status(), gin.H{"error": body})
+}
+
+// FromBinding преобразует ошибку привязки запроса в VALIDATION_FAILED с деталями по полям.
+func FromBinding(err error) *Error {
+	e := New(CodeValidationFailed)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			e.Details = append(e.Details, FieldError{
+				Field: fe.Field(),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+			})
+		}
+		return e
+	}
+
+	return e.WithMessage("request body is malformed")
+}
+
+func fieldMessage(lang i18n.Lang, field, rule, param string) string {
+	label := i18n.Label(field)
+	switch rule {
+	case "required":
+		return i18n.T(lang, "%s is required", label)
+	case "oneof":
+		return i18n.T(lang, "%s must be one of: %s", label, param)
+	case "min", "gte":
+		return i18n.T(lang, "%s must be at least %s", label, param)
+	case "max", "lte":
+		return i18n.T(lang, "%s must be at most %s", label, param)
+	case "datetime":
+		return i18n.T(lang, "%s must be a date in %s format", label, param)
+	case "plate":
+		return i18n.T(lang, "%s is not a valid Kazakhstan plate number", label)
+	case "plate_region":
+		return i18n.T(lang, "%s has an unknown region code", label)
+	case "max_duration":
+		return i18n.T(lang, "%s must be at most %s long", label, param)
+	case "geojson_polygon":
+		return i18n.T(lang, "%s must be a valid GeoJSON polygon", label)
+	case "required_for_type":
+		return i18n.T(lang, "%s is required for vehicle type %s", label, param)
+	case "gtfield":
+		return i18n.T(lang, "%s must be after %s", label, i18n.Label(param))
+	default:
+		return i18n.T(lang, "%s is invalid", label)
+	}
+}
+
+func init() {
+	// Имена полей в деталях ошибок берутся из json-тегов, а не из имён полей структур.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
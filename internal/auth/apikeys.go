@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// APIKeyPrefix начинает каждый ключ: по нему middleware отличает ключ от JWT,
+// а сканеры секретов находят ключи, случайно попавшие в код.
+const APIKeyPrefix = "sok_"
+
+// Ключ имеет вид sok_<12 hex>_<64 hex>: открытый идентификатор и секрет.
+const (
+	keyIDBytes     = 6
+	keySecretBytes = 32
+)
+
+// lastUsedPrecision — как часто обновляется время последнего использования ключа.
+const lastUsedPrecision = time.Minute
+
+// Ошибки проверки API-ключа.
+var (
+	ErrAPIKeyInvalid          = errors.New("api key is invalid")
+	ErrServiceAccountInactive = errors.New("service account is inactive")
+)
+
+// GeneratedAPIKey — новый ключ. Raw показывается клиенту один раз, в базе
+// хранятся только Prefix и Hash.
+type GeneratedAPIKey struct {
+	Raw    string
+	Prefix string
+	Hash   string
+}
+
+// GenerateAPIKey создаёт новый случайный ключ.
+func GenerateAPIKey() (GeneratedAPIKey, error) {
+	id := make([]byte, keyIDBytes)
+	secret := make([]byte, keySecretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return GeneratedAPIKey{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return GeneratedAPIKey{}, err
+	}
+
+	prefix := APIKeyPrefix + hex.EncodeToString(id)
+	secretHex := hex.EncodeToString(secret)
+	return GeneratedAPIKey{
+		Raw:    prefix + "_" + secretHex,
+		Prefix: prefix,
+		Hash:   hashSecret(secretHex),
+	}, nil
+}
+
+// IsAPIKey сообщает, похожа ли строка на API-ключ, а не на JWT.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, APIKeyPrefix)
+}
+
+// splitAPIKey разделяет ключ на открытый префикс и секрет.
+func splitAPIKey(raw string) (prefix, secret string, ok bool) {
+	if !IsAPIKey(raw) {
+		return "", "", false
+	}
+	i := strings.LastIndexByte(raw, '_')
+	if i <= len(APIKeyPrefix) || i == len(raw)-1 {
+		return "", "", false
+	}
+	return raw[:i], raw[i+1:], true
+}
+
+// hashSecret хэширует секрет ключа. Секрет случайный и длинный, поэтому
+// медленный хэш для паролей не нужен.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+type keyCacheEntry struct {
+	key       models.APIKey
+	principal *Principal
+	err       error
+	expires   time.Time
+}
+
+// ResolveAPIKey проверяет ключ и возвращает субъекта служебной учётной записи
+// с ролью администратора её организации и выданными разрешениями. Неизвестные,
+// отозванные и истёкшие ключи дают ErrAPIKeyInvalid; отключённые учётная запись
+// или организация — ErrServiceAccountInactive или ErrOrgInactive.
+func (r *Resolver) ResolveAPIKey(ctx context.Context, raw string) (*Principal, error) {
+	prefix, secret, ok := splitAPIKey(raw)
+	if !ok {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := r.now()
+
+	r.mu.Lock()
+	entry, cached := r.keys[prefix]
+	r.mu.Unlock()
+	if !cached || !now.Before(entry.expires) {
+		var err error
+		entry, err = r.loadAPIKey(ctx, prefix, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Секрет сверяется при каждом запросе, в том числе для записи из кэша.
+	if entry.key.Hash == "" || subtle.ConstantTimeCompare([]byte(entry.key.Hash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrAPIKeyInvalid
+	}
+	if !entry.key.IsUsable(now) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	r.touchAPIKey(ctx, prefix, entry, now)
+	return entry.principal, nil
+}
+
+// InvalidateAPIKey удаляет ключ из кэша, чтобы отзыв или смена разрешений вступили
+// в силу сразу в этом экземпляре сервиса; другие экземпляры перечитают ключ по
+// истечении ttl.
+func (r *Resolver) InvalidateAPIKey(prefix string) {
+	r.mu.Lock()
+	delete(r.keys, prefix)
+	r.mu.Unlock()
+}
+
+func (r *Resolver) loadAPIKey(ctx context.Context, prefix string, now time.Time) (keyCacheEntry, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).
+		Preload("ServiceAccount.Organization").
+		Where("prefix = ?", prefix).
+		First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Отсутствующий ключ тоже кэшируется, чтобы перебор не нагружал базу.
+		key = models.APIKey{}
+	} else if err != nil {
+		return keyCacheEntry{}, err
+	}
+
+	entry := keyCacheEntry{key: key, expires: now.Add(r.ttl)}
+	account := key.ServiceAccount
+	switch {
+	case key.Hash == "":
+	case account == nil || !account.IsActive:
+		entry.err = ErrServiceAccountInactive
+	case account.Organization == nil || !account.Organization.IsActive:
+		entry.err = ErrOrgInactive
+	default:
+		accountID := account.ID
+		entry.principal = &Principal{
+			ServiceAccountID: &accountID,
+			Role:             models.AdminRoleForOrgType(account.Organization.Type),
+			OrganizationID:   account.OrganizationID,
+			OrganizationType: account.Organization.Type,
+			Scopes:           account.Scopes,
+		}
+	}
+	// Связанные записи в кэше не нужны.
+	entry.key.ServiceAccount = nil
+
+	r.mu.Lock()
+	r.keys[prefix] = entry
+	r.evictExpired(now)
+	r.mu.Unlock()
+
+	return entry, nil
+}
+
+// touchAPIKey отмечает использование ключа не чаще раза в lastUsedPrecision.
+func (r *Resolver) touchAPIKey(ctx context.Context, prefix string, entry keyCacheEntry, now time.Time) {
+	if entry.key.LastUsedAt != nil && now.Sub(*entry.key.LastUsedAt) < lastUsedPrecision {
+		return
+	}
+
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", entry.key.ID).
+		Update("last_used_at", now).Error; err != nil {
+		slog.WarnContext(ctx, "не удалось обновить время использования API-ключа", "key_prefix", prefix, "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	if cached, ok := r.keys[prefix]; ok {
+		cached.key.LastUsedAt = &now
+		r.keys[prefix] = cached
+	}
+	r.mu.Unlock()
+}
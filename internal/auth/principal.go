@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+)
+
+// PrincipalKey — ключ gin-контекста, под которым middleware аутентификации
+// сохраняет текущего пользователя.
+const PrincipalKey = "principal"
+
+// ScopeAll разрешает все действия, доступные роли. Пользователи получают его
+// всегда; ограниченный набор разрешений бывает у служебных учётных записей.
+const ScopeAll = "*"
+
+// Principal — аутентифицированный субъект запроса или фоновой операции:
+// пользователь или служебная учётная запись (тогда UserID пуст, а
+// ServiceAccountID задан).
+// Роль и организация берутся из базы, а не из токена. Один и тот же субъект
+// может отдаваться из кэша нескольким запросам, поэтому его не изменяют.
+type Principal struct {
+	UserID           uuid.UUID
+	ServiceAccountID *uuid.UUID
+	Role             string
+	OrganizationID   uuid.UUID
+	OrganizationType string
+	DriverID         *uuid.UUID
+	Scopes           []string
+}
+
+// IsServiceAccount сообщает, что субъект — служебная учётная запись.
+func (p *Principal) IsServiceAccount() bool {
+	return p.ServiceAccountID != nil
+}
+
+// Subject возвращает идентификатор субъекта для журнала и трассировки.
+func (p *Principal) Subject() string {
+	if p.ServiceAccountID != nil {
+		return "service_account:" + p.ServiceAccountID.String()
+	}
+	return p.UserID.String()
+}
+
+// HasScope сообщает, разрешено ли субъекту действие scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, ScopeAll) || slices.Contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// NewContext возвращает контекст с субъектом — для обработчиков, фоновых
+// задач и утилит, которые действуют от имени пользователя.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext возвращает субъекта из контекста.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// SetGin сохраняет субъекта в gin-контексте и в контексте запроса.
+// Вызывается middleware аутентификации.
+func SetGin(c *gin.Context, principal *Principal) {
+	c.Set(PrincipalKey, principal)
+	c.Request = c.Request.WithContext(NewContext(c.Request.Context(), principal))
+}
+
+// FromGin возвращает субъекта, установленного middleware аутентификации.
+func FromGin(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(PrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok && principal != nil
+}
+
+// Require возвращает субъекта запроса; если его нет, отвечает 401 и
+// возвращает false — обработчику остаётся только выйти.
+func Require(c *gin.Context) (*Principal, bool) {
+	principal, ok := FromGin(c)
+	if !ok {
+		apierror.Respond(c, apierror.New(apierror.CodeUnauthorized))
+		return nil, false
+	}
+	return principal, true
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// DefaultCacheTTL — сколько результат проверки пользователя хранится в кэше.
+// Изменение роли или отключение организации вступает в силу не позже этого срока.
+const DefaultCacheTTL = 30 * time.Second
+
+// Ошибки проверки пользователя.
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserInactive = errors.New("user is inactive")
+	ErrOrgInactive  = errors.New("organization is inactive")
+)
+
+type cacheEntry struct {
+	principal *Principal
+	err       error
+	expires   time.Time
+}
+
+// Resolver загружает пользователя по идентификатору из токена или служебную
+// учётную запись по API-ключу и проверяет, что они и их организация активны. Результаты, включая отказы, кэшируются
+// на короткое время, чтобы не обращаться к базе на каждый запрос.
+type Resolver struct {
+	db  *gorm.DB
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cacheEntry
+	keys  map[string]keyCacheEntry
+}
+
+// NewResolver создаёт проверку пользователей с кэшем на ttl; при ttl <= 0
+// используется DefaultCacheTTL.
+func NewResolver(db *gorm.DB, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Resolver{
+		db:    db,
+		ttl:   ttl,
+		now:   time.Now,
+		cache: make(map[uuid.UUID]cacheEntry),
+		keys:  make(map[string]keyCacheEntry),
+	}
+}
+
+// Resolve возвращает пользователя с ролью и организацией из базы. Ошибки базы
+// не кэшируются; для отсутствующих и отключённых пользователей возвращаются
+// ErrUserNotFound, ErrUserInactive или ErrOrgInactive.
+func (r *Resolver) Resolve(ctx context.Context, userID uuid.UUID) (*Principal, error) {
+	now := r.now()
+
+	r.mu.Lock()
+	entry, ok := r.cache[userID]
+	r.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.principal, entry.err
+	}
+
+	principal, err := r.load(ctx, userID)
+	if err != nil && !isRejection(err) {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[userID] = cacheEntry{principal: principal, err: err, expires: now.Add(r.ttl)}
+	r.evictExpired(now)
+	r.mu.Unlock()
+
+	return principal, err
+}
+
+func (r *Resolver) load(ctx context.Context, userID uuid.UUID) (*Principal, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Preload("Organization").First(&user, "id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrUserInactive
+	}
+	if user.Organization == nil || !user.Organization.IsActive {
+		return nil, ErrOrgInactive
+	}
+
+	return &Principal{
+		UserID:           user.ID,
+		Role:             user.Role,
+		OrganizationID:   user.Organization.ID,
+		OrganizationType: user.Organization.Type,
+		DriverID:         user.DriverID,
+		Scopes:           []string{ScopeAll},
+	}, nil
+}
+
+// evictExpired удаляет устаревшие записи, когда кэш разрастается.
+func (r *Resolver) evictExpired(now time.Time) {
+	if len(r.cache)+len(r.keys) < 1024 {
+		return
+	}
+	for id, entry := range r.cache {
+		if !now.Before(entry.expires) {
+			delete(r.cache, id)
+		}
+	}
+	for prefix, entry := range r.keys {
+		if !now.Before(entry.expires) {
+			delete(r.keys, prefix)
+		}
+	}
+}
+
+func isRejection(err error) bool {
+	return errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrUserInactive) || errors.Is(err, ErrOrgInactive)
+}
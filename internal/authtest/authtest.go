@@ -0,0 +1,52 @@
+// Package authtest выпускает настоящие JWT для тестов и локальной разработки,
+// чтобы не полагаться на подмену заголовков в режиме mock. В рабочем коде
+// сервиса не используется.
+package authtest
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/MSTimX/Snowops-roles/internal/middleware"
+)
+
+// DefaultTTL — срок действия токена, если он не указан.
+const DefaultTTL = time.Hour
+
+// Identity описывает пользователя, для которого выпускается токен.
+type Identity struct {
+	UserID         string
+	Role           string
+	OrganizationID string
+}
+
+// MintToken подписывает токен HS256 тем же секретом, что проверяет
+// JWTAuthMiddleware. При ttl <= 0 используется DefaultTTL.
+func MintToken(secret string, identity Identity, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	now := time.Now()
+	claims := middleware.UserClaims{
+		UserID:         identity.UserID,
+		Role:           identity.Role,
+		OrganizationID: identity.OrganizationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   identity.UserID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// AuthorizationHeader возвращает значение заголовка Authorization с новым токеном.
+func AuthorizationHeader(secret string, identity Identity, ttl time.Duration) (string, error) {
+	token, err := MintToken(secret, identity, ttl)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+)
+
+// Режимы аутентификации. Mock доверяет заголовкам X-User-* и допускается
+// только в окружении development.
+const (
+	AuthModeJWT  = "jwt"
+	AuthModeMock = "mock"
+)
+
+// Окружения запуска.
+const (
+	EnvProduction  = "production"
+	EnvDevelopment = "development"
+)
+
+// Config — полная конфигурация сервиса. Значения берутся по возрастанию
+// приоритета: значения по умолчанию, YAML-файл из CONFIG_FILE, переменные
+// окружения (в том числе из .env).
+type Config struct {
+	Env      string         `yaml:"env"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Pass     PassConfig     `yaml:"pass"`
+}
+
+// HTTPConfig — адрес и таймауты HTTP-сервера.
+type HTTPConfig struct {
+	Port              string        `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig — параметры подключения к PostgreSQL.
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	TimeZone string `yaml:"timezone"`
+}
+
+// AuthConfig — режим аутентификации, секрет для проверки JWT и срок, на
+// который кэшируется проверка пользователя по базе.
+type AuthConfig struct {
+	Mode             string        `yaml:"mode"`
+	JWTSecret        string        `yaml:"jwt_secret"`
+	IdentityCacheTTL time.Duration `yaml:"identity_cache_ttl"`
+}
+
+// LogConfig — параметры журнала.
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+// TracingConfig — выбор экспортёра трассировки. Адрес и заголовки OTLP
+// задаются стандартными переменными OTEL_EXPORTER_OTLP_*.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter"`
+}
+
+// PassConfig — ключ подписи пропусков (seed Ed25519 в base64); обязателен при APP_ENV=production.
+type PassConfig struct {
+	SigningKey string `yaml:"signing_key"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+func Default() Config {
+	return Config{
+		Env: EnvProduction,
+		HTTP: HTTPConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   25 * time.Second,
+		},
+		Database: DatabaseConfig{
+			SSLMode:  "disable",
+			TimeZone: "Asia/Almaty",
+		},
+		Auth:    AuthConfig{Mode: AuthModeJWT, IdentityCacheTTL: 30 * time.Second},
+		Log:     LogConfig{Level: "info"},
+		Tracing: TracingConfig{Exporter: "none"},
+	}
+}
+
+// Load читает .env (если есть), YAML-файл из CONFIG_FILE (если задан) и
+// переменные окружения, после чего проверяет результат. Ошибка перечисляет
+// все найденные проблемы сразу.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+	if path := strings.TrimSpace(os.Getenv("CONFIG_FILE")); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.UnmarshalWithOptions(data, &cfg, yaml.Strict()); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := errors.Join(cfg.applyEnv(), cfg.Validate()); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyEnv переопределяет значения заданными переменными окружения.
+func (c *Config) applyEnv() error {
+	strs := []struct {
+		key   string
+		value *string
+	}{
+		{"APP_ENV", &c.Env},
+		{"APP_PORT", &c.HTTP.Port},
+		{"DB_HOST", &c.Database.Host},
+		{"DB_PORT", &c.Database.Port},
+		{"DB_USER", &c.Database.User},
+		{"DB_PASSWORD", &c.Database.Password},
+		{"DB_NAME", &c.Database.Name},
+		{"DB_SSLMODE", &c.Database.SSLMode},
+		{"DB_TIMEZONE", &c.Database.TimeZone},
+		{"AUTH_MODE", &c.Auth.Mode},
+		{"JWT_SECRET", &c.Auth.JWTSecret},
+		{"LOG_LEVEL", &c.Log.Level},
+		{"OTEL_TRACES_EXPORTER", &c.Tracing.Exporter},
+		{"PASS_SIGNING_KEY", &c.Pass.SigningKey},
+	}
+	for _, s := range strs {
+		if value, ok := os.LookupEnv(s.key); ok && strings.TrimSpace(value) != "" {
+			*s.value = strings.TrimSpace(value)
+		}
+	}
+
+	durations := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", &c.HTTP.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", &c.HTTP.ShutdownTimeout},
+		{"AUTH_IDENTITY_CACHE_TTL", &c.Auth.IdentityCacheTTL},
+	}
+	var errs []error
+	for _, d := range durations {
+		value := strings.TrimSpace(os.Getenv(d.key))
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid duration %q (expected e.g. 30s)", d.key, value))
+			continue
+		}
+		*d.value = parsed
+	}
+	return errors.Join(errs...)
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	c.Env = strings.ToLower(c.Env)
+	switch c.Env {
+	case EnvProduction, EnvDevelopment:
+	default:
+		fail("env (APP_ENV): expected %q or %q, got %q", EnvProduction, EnvDevelopment, c.Env)
+	}
+
+	portPart := c.HTTP.Port[strings.LastIndex(c.HTTP.Port, ":")+1:]
+	if port, err := strconv.Atoi(portPart); err != nil || port < 1 || port > 65535 {
+		fail("http.port (APP_PORT): invalid port %q", c.HTTP.Port)
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"http.read_header_timeout (HTTP_READ_HEADER_TIMEOUT)", c.HTTP.ReadHeaderTimeout},
+		{"http.read_timeout (HTTP_READ_TIMEOUT)", c.HTTP.ReadTimeout},
+		{"http.write_timeout (HTTP_WRITE_TIMEOUT)", c.HTTP.WriteTimeout},
+		{"http.idle_timeout (HTTP_IDLE_TIMEOUT)", c.HTTP.IdleTimeout},
+		{"http.shutdown_timeout (SHUTDOWN_TIMEOUT)", c.HTTP.ShutdownTimeout},
+		{"auth.identity_cache_ttl (AUTH_IDENTITY_CACHE_TTL)", c.Auth.IdentityCacheTTL},
+	} {
+		if d.value <= 0 {
+			fail("%s: must be positive", d.name)
+		}
+	}
+
+	for _, r := range []struct{ name, value string }{
+		{"database.host (DB_HOST)", c.Database.Host},
+		{"database.port (DB_PORT)", c.Database.Port},
+		{"database.user (DB_USER)", c.Database.User},
+		{"database.password (DB_PASSWORD)", c.Database.Password},
+		{"database.name (DB_NAME)", c.Database.Name},
+	} {
+		if r.value == "" {
+			fail("%s: required", r.name)
+		}
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		fail("database.sslmode (DB_SSLMODE): unsupported value %q", c.Database.SSLMode)
+	}
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil || c.Database.TimeZone == "" {
+		fail("database.timezone (DB_TIMEZONE): unknown time zone %q", c.Database.TimeZone)
+	}
+
+	c.Auth.Mode = strings.ToLower(c.Auth.Mode)
+	switch c.Auth.Mode {
+	case AuthModeJWT:
+		if c.Auth.JWTSecret == "" {
+			fail("auth.jwt_secret (JWT_SECRET): required when auth mode is jwt")
+		}
+	case AuthModeMock:
+		if c.Env != EnvDevelopment {
+			fail("auth.mode (AUTH_MODE): mock authentication trusts request headers and is allowed only with APP_ENV=%s", EnvDevelopment)
+		}
+	default:
+		fail("auth.mode (AUTH_MODE): expected %q or %q, got %q", AuthModeJWT, AuthModeMock, c.Auth.Mode)
+	}
+
+	if _, err := c.Log.SlogLevel(); err != nil {
+		fail("log.level (LOG_LEVEL): expected debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	c.Tracing.Exporter = strings.ToLower(c.Tracing.Exporter)
+	switch c.Tracing.Exporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		fail("tracing.exporter (OTEL_TRACES_EXPORTER): expected none, stdout or otlp, got %q", c.Tracing.Exporter)
+	}
+
+	if c.Pass.SigningKey == "" {
+		if c.Env == EnvProduction {
+			fail("pass.signing_key (PASS_SIGNING_KEY): required when APP_ENV=%s", EnvProduction)
+		}
+	} else {
+		seed, err := base64.StdEncoding.DecodeString(c.Pass.SigningKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			fail("pass.signing_key (PASS_SIGNING_KEY): expected base64 of %d bytes", ed25519.SeedSize)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Address возвращает адрес для http.Server: порт без хоста дополняется двоеточием.
+func (c HTTPConfig) Address() string {
+	if strings.Contains(c.Port, ":") {
+		return c.Port
+	}
+	return ":" + c.Port
+}
+
+// DSN собирает строку подключения к PostgreSQL. Значения экранируются, чтобы
+// пробелы и кавычки в пароле не ломали строку.
+func (c DatabaseConfig) DSN() string {
+	pairs := []struct{ key, value string }{
+		{"host", c.Host},
+		{"user", c.User},
+		{"password", c.Password},
+		{"dbname", c.Name},
+		{"port", c.Port},
+		{"sslmode", c.SSLMode},
+		{"TimeZone", c.TimeZone},
+	}
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p.key+"="+quoteDSN(p.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + value + "'"
+}
+
+// SlogLevel разбирает уровень журнала.
+func (c LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Level))
+	return level, err
+}
+
+// Redacted возвращает описание конфигурации для журнала без секретов.
+func (c Config) Redacted() []any {
+	return []any{
+		"env", c.Env,
+		"address", c.HTTP.Address(),
+		"database", (&url.URL{Scheme: "postgres", Host: c.Database.Host + ":" + c.Database.Port, Path: c.Database.Name}).String(),
+		"sslmode", c.Database.SSLMode,
+		"timezone", c.Database.TimeZone,
+		"auth_mode", c.Auth.Mode,
+		"log_level", c.Log.Level,
+		"tracing_exporter", c.Tracing.Exporter,
+	}
+}
@@ -1,72 +1,360 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
 
+	"github.com/MSTimX/Snowops-roles/internal/config"
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/logging"
 	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/plate"
 )
 
 // DB хранит глобальное подключение к базе данных.
 var DB *gorm.DB
 
-// Init загружает конфигурацию и инициализирует подключение к PostgreSQL.
-func Init() {
-	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
-		log.Fatalf("не удалось загрузить .env файл: %v", err)
-	}
+// SchemaVersion — версия схемы, которую ожидает этот код. Увеличивается при
+// каждом изменении моделей или миграций.
+const SchemaVersion = 3
 
-	required := []string{
-		"DB_HOST",
-		"DB_PORT",
-		"DB_USER",
-		"DB_PASSWORD",
-		"DB_NAME",
-	}
+// platesNormalizedVersion — версия схемы, начиная с которой номера техники
+// хранятся в канонической форме; нормализация выполняется один раз при переходе на неё.
+const platesNormalizedVersion = 3
 
-	config := make(map[string]string, len(required))
-	for _, key := range required {
-		value := os.Getenv(key)
-		if value == "" {
-			log.Fatalf("переменная окружения %s не установлена", key)
-		}
-		config[key] = value
-	}
+// Параметры повторных попыток подключения к базе при старте.
+const (
+	connectMinBackoff = time.Second
+	connectMaxBackoff = 30 * time.Second
+)
 
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Almaty",
-		config["DB_HOST"],
-		config["DB_USER"],
-		config["DB_PASSWORD"],
-		config["DB_NAME"],
-		config["DB_PORT"],
-	)
+// Init подключается к PostgreSQL. Пока база недоступна, подключение
+// повторяется с экспоненциальной задержкой до отмены ctx.
+func Init(ctx context.Context, cfg config.DatabaseConfig) error {
+	dsn := cfg.DSN()
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("не удалось подключиться к базе данных: %v", err)
-	}
+	delay := connectMinBackoff
+	for attempt := 1; ; attempt++ {
+		// Ошибку подключения пишет цикл повторов, поэтому при открытии журнал GORM отключён.
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			TranslateError: true,
+			Logger:         logger.Discard,
+		})
+		if err == nil {
+			// Запросы пишутся в общий журнал без значений параметров: в них телефоны и ИИН.
+			db.Logger = logger.NewSlogLogger(slog.Default(), logger.Config{
+				LogLevel:                  logger.Warn,
+				SlowThreshold:             200 * time.Millisecond,
+				ParameterizedQueries:      true,
+				IgnoreRecordNotFoundError: true,
+			})
+			DB = db
+			return nil
+		}
 
-	DB = db
+		slog.Warn("база данных недоступна, повторное подключение",
+			"attempt", attempt,
+			"retry_in", delay.String(),
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectMaxBackoff)
+	}
 }
 
 // Migrate выполняет авто-миграции для всех моделей.
 func Migrate() {
 	if DB == nil {
-		log.Fatalf("подключение к базе данных не инициализировано")
+		logging.Fatal("подключение к базе данных не инициализировано")
+	}
+
+	applied, err := appliedSchemaVersion(context.Background())
+	if err != nil && DB.Migrator().HasTable(&models.SchemaMigration{}) {
+		logging.Fatal("не удалось прочитать версию схемы", "error", err)
 	}
 
+	// Частичные уникальные индексы по активным записям не создаются, если в базе
+	// уже есть дубликаты; их нужно разрешить до авто-миграции.
+	resolveDuplicateDrivers()
+	checkActiveDuplicates()
+
 	if err := DB.AutoMigrate(
 		&models.Organization{},
 		&models.User{},
 		&models.Driver{},
 		&models.Vehicle{},
+		&models.DriverDocument{},
+		&models.VehicleDocument{},
+		&models.Contract{},
+		&models.ServiceArea{},
+		&models.Shift{},
+		&models.PhoneVerification{},
+		&models.OutboxEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.ServiceAccount{},
+		&models.APIKey{},
+		&models.SchemaMigration{},
 	); err != nil {
-		log.Fatalf("ошибка авто-миграции: %v", err)
+		logging.Fatal("ошибка авто-миграции", "error", err)
+	}
+
+	// Старые безусловные индексы на телефон пользователя и госномер не позволяли
+	// повторно использовать значения уволенных водителей и списанной техники; их
+	// заменяют частичные индексы по активным записям.
+	dropLegacyIndex(&models.User{}, "idx_users_phone")
+	dropLegacyIndex(&models.Vehicle{}, "idx_vehicles_plate_number")
+
+	if applied < platesNormalizedVersion {
+		normalizeVehiclePlates()
+	}
+
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SchemaMigration{Version: SchemaVersion, AppliedAt: time.Now()}).Error; err != nil {
+		logging.Fatal("не удалось записать версию схемы", "error", err)
+	}
+}
+
+// dropLegacyIndex удаляет индекс, если он остался от прежней схемы.
+func dropLegacyIndex(model any, name string) {
+	if !DB.Migrator().HasIndex(model, name) {
+		return
+	}
+	if err := DB.Migrator().DropIndex(model, name); err != nil {
+		logging.Fatal("не удалось удалить индекс "+name, "error", err)
+	}
+}
+
+// uniqueActiveIndex — частичный уникальный индекс по активным записям таблицы.
+type uniqueActiveIndex struct {
+	model     any
+	table     string
+	column    string
+	name      string
+	condition string
+}
+
+// driverIndexes — уникальные индексы водителей, дубликаты по которым разрешаются
+// автоматически; reportedIndexes — индексы, дубликаты по которым нужно разрешить вручную.
+var (
+	driverIndexes = []uniqueActiveIndex{
+		{&models.Driver{}, "drivers", "iin", "idx_drivers_iin_active", "is_active = true"},
+		{&models.Driver{}, "drivers", "phone", "idx_drivers_phone_active", "is_active = true"},
+	}
+	reportedIndexes = []uniqueActiveIndex{
+		{&models.Organization{}, "organizations", "bin", "idx_organizations_bin_active", "is_active = true AND bin <> ''"},
+		{&models.User{}, "users", "phone", "idx_users_phone_active", "is_active = true"},
+		{&models.Vehicle{}, "vehicles", "plate_number", "idx_vehicles_plate_number_active", "is_active = true"},
+	}
+)
+
+// pending сообщает, что индекс ещё не создан, а таблица уже есть, то есть перед
+// его созданием нужно проверить дубликаты.
+func (i uniqueActiveIndex) pending() bool {
+	return DB.Migrator().HasTable(i.model) && !DB.Migrator().HasIndex(i.model, i.name)
+}
+
+// duplicates возвращает группы активных записей с одинаковым значением столбца
+// индекса. В каждой группе записи упорядочены от последней изменённой к более старым.
+func (i uniqueActiveIndex) duplicates() ([][]uuid.UUID, error) {
+	var groups []string
+	err := DB.Raw(fmt.Sprintf(
+		"SELECT string_agg(id::text, ',' ORDER BY updated_at DESC, created_at DESC, id DESC) FROM %s WHERE %s GROUP BY %s HAVING count(*) > 1",
+		i.table, i.condition, i.column,
+	)).Scan(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]uuid.UUID, 0, len(groups))
+	for _, group := range groups {
+		var ids []uuid.UUID
+		for _, raw := range strings.Split(group, ",") {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		result = append(result, ids)
+	}
+	return result, nil
+}
+
+// resolveDuplicateDrivers разрешает дубликаты активных водителей по ИИН и телефону,
+// накопленные до появления уникальных индексов: в каждой группе остаётся активным
+// последний изменённый водитель, остальные увольняются вместе с учётными записями.
+func resolveDuplicateDrivers() {
+	for _, index := range driverIndexes {
+		if !index.pending() {
+			continue
+		}
+
+		groups, err := index.duplicates()
+		if err != nil {
+			logging.Fatal("не удалось проверить дубликаты водителей", "column", index.column, "error", err)
+		}
+		for _, ids := range groups {
+			if err := deactivateDrivers(ids[1:]); err != nil {
+				logging.Fatal("не удалось деактивировать дубликаты водителей", "column", index.column, "kept", ids[0], "error", err)
+			}
+			slog.Warn("дубликаты активных водителей деактивированы",
+				"column", index.column,
+				"kept", ids[0],
+				"deactivated", ids[1:],
+			)
+		}
+	}
+}
+
+// deactivateDrivers увольняет водителей и блокирует их учётные записи так же, как
+// удаление водителя через API, включая событие DriverDeactivated.
+func deactivateDrivers(ids []uuid.UUID) error {
+	// До появления outbox событие записать некуда.
+	recordEvents := DB.Migrator().HasTable(&models.OutboxEvent{})
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var drivers []models.Driver
+		if err := tx.Where("id IN ?", ids).Find(&drivers).Error; err != nil {
+			return err
+		}
+		for _, driver := range drivers {
+			if err := tx.Model(&driver).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.User{}).Where("driver_id = ?", driver.ID).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			if !recordEvents {
+				continue
+			}
+			if err := events.RecordDriver(tx, events.DriverDeactivated, driver); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkActiveDuplicates останавливает запуск с перечнем конфликтующих записей,
+// если создать уникальный индекс мешают дубликаты, которые нельзя разрешить
+// автоматически.
+func checkActiveDuplicates() {
+	conflicts := 0
+	for _, index := range reportedIndexes {
+		if !index.pending() {
+			continue
+		}
+
+		groups, err := index.duplicates()
+		if err != nil {
+			logging.Fatal("не удалось проверить дубликаты", "table", index.table, "column", index.column, "error", err)
+		}
+		for _, ids := range groups {
+			slog.Error("дубликаты активных записей мешают созданию уникального индекса",
+				"index", index.name,
+				"ids", ids,
+			)
+		}
+		conflicts += len(groups)
+	}
+
+	if conflicts > 0 {
+		logging.Fatal("в базе есть дубликаты активных записей; деактивируйте лишние записи и перезапустите сервис", "groups", conflicts)
+	}
+}
+
+// normalizeVehiclePlates приводит номера техники, сохранённые до появления разбора
+// номеров, к канонической форме. Некорректные номера и номера, канонический вид
+// которых уже занят другой записью, остаются без изменений и попадают в журнал.
+// Выполняется один раз при переходе на platesNormalizedVersion.
+func normalizeVehiclePlates() {
+	var vehicles []models.Vehicle
+	if err := DB.Where("plate_display = '' OR plate_display IS NULL").Find(&vehicles).Error; err != nil {
+		logging.Fatal("не удалось загрузить технику для нормализации номеров", "error", err)
+	}
+
+	normalized := 0
+	for _, vehicle := range vehicles {
+		parsed, err := plate.Parse(vehicle.PlateNumber)
+		if err != nil {
+			slog.Warn("номер техники не распознан", "plate", vehicle.PlateNumber, "vehicle_id", vehicle.ID, "error", err)
+			continue
+		}
+
+		if err := DB.Model(&vehicle).Updates(map[string]interface{}{
+			"plate_number":  parsed.Canonical,
+			"plate_display": parsed.Display,
+		}).Error; err != nil {
+			slog.Warn("не удалось нормализовать номер техники", "plate", vehicle.PlateNumber, "vehicle_id", vehicle.ID, "error", err)
+			continue
+		}
+		normalized++
+	}
+
+	slog.Info("номера техники нормализованы", "normalized", normalized, "skipped", len(vehicles)-normalized)
+}
+
+// WithContext возвращает подключение, привязанное к контексту запроса: запросы
+// к базе попадают в его трассировку и прерываются вместе с ним.
+func WithContext(ctx context.Context) *gorm.DB {
+	return DB.WithContext(ctx)
+}
+
+// Ping проверяет, что база отвечает.
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// appliedSchemaVersion возвращает последнюю записанную версию схемы или 0.
+func appliedSchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := WithContext(ctx).Model(&models.SchemaMigration{}).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// CheckSchema проверяет, что база приведена как минимум к SchemaVersion.
+func CheckSchema(ctx context.Context) error {
+	version, err := appliedSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("schema version %d, expected %d", version, SchemaVersion)
+	}
+	return nil
+}
+
+// Close закрывает пул подключений к базе.
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
 	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubDB подменяет DB заглушкой, ожидающей запросы по порядку.
+func stubDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	previous := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = previous
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("database: %v", err)
+		}
+	})
+	return mock
+}
+
+func count(n int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"count"}).AddRow(n)
+}
+
+// TestResolveDuplicateDrivers проверяет, что из группы активных водителей с одним
+// ИИН остаётся последний изменённый, а остальные увольняются вместе с учётными записями.
+func TestResolveDuplicateDrivers(t *testing.T) {
+	mock := stubDB(t)
+	kept, stale := uuid.New(), uuid.New()
+
+	hasTable := regexp.QuoteMeta(`FROM information_schema.tables`)
+	hasIndex := regexp.QuoteMeta(`FROM pg_indexes`)
+
+	// Индекс по ИИН ещё не создан, и в базе есть дубликаты.
+	mock.ExpectQuery(hasTable).WillReturnRows(count(1))
+	mock.ExpectQuery(hasIndex).WillReturnRows(count(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT string_agg(id::text, ',' ORDER BY updated_at DESC, created_at DESC, id DESC) FROM drivers WHERE is_active = true GROUP BY iin HAVING count(*) > 1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"string_agg"}).AddRow(kept.String() + "," + stale.String()))
+
+	// Таблица outbox есть, поэтому увольнение сопровождается событием.
+	mock.ExpectQuery(hasTable).WillReturnRows(count(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "drivers" WHERE id IN ($1)`)).
+		WithArgs(stale).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "is_active", "updated_at"}).AddRow(stale, true, time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "drivers" SET "is_active"=$1,"updated_at"=$2 WHERE "id" = $3`)).
+		WithArgs(false, sqlmock.AnyArg(), stale).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "is_active"=$1,"updated_at"=$2 WHERE driver_id = $3`)).
+		WithArgs(false, sqlmock.AnyArg(), stale).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(int64(1)))
+	mock.ExpectCommit()
+
+	// Индекс по телефону уже есть: дубликатов быть не может.
+	mock.ExpectQuery(hasTable).WillReturnRows(count(1))
+	mock.ExpectQuery(hasIndex).WillReturnRows(count(1))
+
+	resolveDuplicateDrivers()
+}
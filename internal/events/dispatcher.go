@@ -0,0 +1,165 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// Publisher доставляет событие подписчикам. Ошибка означает, что событие будет
+// доставлено повторно; подписчики должны быть идемпотентны по Envelope.ID.
+type Publisher interface {
+	Publish(ctx context.Context, event Envelope) error
+}
+
+// PublisherFunc позволяет использовать функцию как Publisher.
+type PublisherFunc func(ctx context.Context, event Envelope) error
+
+func (f PublisherFunc) Publish(ctx context.Context, event Envelope) error {
+	return f(ctx, event)
+}
+
+// LogPublisher пишет события в журнал; используется, пока не подключены подписчики.
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(_ context.Context, event Envelope) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	slog.Info("событие", "type", event.Type, "event", string(body))
+	return nil
+}
+
+// maxErrorLength ограничивает текст последней ошибки доставки в outbox.
+const maxErrorLength = 1024
+
+// Dispatcher периодически выбирает недоставленные события из outbox и передаёт
+// их Publisher. Доставка — не менее одного раза: событие помечается доставленным
+// только после успешной публикации. Внутри агрегата события доставляются строго
+// по порядку: следующее не выбирается, пока не доставлено предыдущее.
+type Dispatcher struct {
+	db        *gorm.DB
+	publisher Publisher
+
+	BatchSize    int
+	PollInterval time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+}
+
+// NewDispatcher создаёт диспетчер с параметрами по умолчанию.
+func NewDispatcher(db *gorm.DB, publisher Publisher) *Dispatcher {
+	return &Dispatcher{
+		db:           db,
+		publisher:    publisher,
+		BatchSize:    100,
+		PollInterval: 2 * time.Second,
+		MinBackoff:   5 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+	}
+}
+
+// Run обрабатывает outbox до отмены ctx.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("ошибка обработки outbox", "error", err)
+		}
+
+		// Полная пачка означает, что в очереди, вероятно, есть ещё события.
+		if err == nil && processed == d.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce обрабатывает одну пачку событий и возвращает их количество.
+// Выбранные строки блокируются до конца транзакции, поэтому несколько экземпляров
+// сервиса не доставляют одно событие одновременно.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	processed := 0
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var batch []models.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL AND next_attempt_at <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_events prev
+				WHERE prev.aggregate_type = outbox_events.aggregate_type
+				AND prev.aggregate_id = outbox_events.aggregate_id
+				AND prev.delivered_at IS NULL
+				AND prev.sequence < outbox_events.sequence)`).
+			Order("sequence").
+			Limit(d.BatchSize).
+			Find(&batch).Error; err != nil {
+			return err
+		}
+
+		for _, event := range batch {
+			processed++
+			attempts := event.Attempts + 1
+
+			if err := d.publisher.Publish(ctx, ToEnvelope(event)); err != nil {
+				message := err.Error()
+				if len(message) > maxErrorLength {
+					message = message[:maxErrorLength]
+				}
+				slog.Warn("не удалось доставить событие", "event_id", event.ID, "type", event.Type, "attempt", attempts, "error", err)
+
+				if err := tx.Model(&event).Updates(map[string]interface{}{
+					"attempts":        attempts,
+					"next_attempt_at": time.Now().Add(d.backoff(attempts)),
+					"last_error":      message,
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := tx.Model(&event).Updates(map[string]interface{}{
+				"attempts":     attempts,
+				"delivered_at": time.Now(),
+				"last_error":   "",
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return processed, err
+}
+
+// backoff возвращает экспоненциально растущую задержку перед следующей попыткой.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.MinBackoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,186 @@
+package events
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stubDB возвращает подключение GORM к заглушке, ожидающей запросы по порядку.
+func stubDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("database: %v", err)
+		}
+	})
+	return db, mock
+}
+
+// pendingEvent — недоставленное событие в том виде, в каком его возвращает выборка outbox.
+type pendingEvent struct {
+	sequence    int64
+	aggregateID uuid.UUID
+	attempts    int
+}
+
+// expectBatch ожидает выборку пачки outbox и возвращает события в порядке sequence.
+func expectBatch(mock sqlmock.Sqlmock, batch ...pendingEvent) {
+	result := sqlmock.NewRows([]string{"sequence", "id", "aggregate_type", "aggregate_id", "type", "payload", "attempts"})
+	for _, event := range batch {
+		result.AddRow(event.sequence, uuid.New(), AggregateDriver, event.aggregateID, DriverUpdated, `{}`, event.attempts)
+	}
+	mock.ExpectQuery(`prev\.sequence < outbox_events\.sequence\)\s+ORDER BY sequence LIMIT \$2 FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(result)
+}
+
+// expectDelivered ожидает отметку о доставке события.
+func expectDelivered(mock sqlmock.Sqlmock, sequence int64, attempts int) {
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=$1,"delivered_at"=$2,"last_error"=$3 WHERE "sequence" = $4`)).
+		WithArgs(attempts, sqlmock.AnyArg(), "", sequence).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// after сопоставляет момент времени не раньше заданного.
+type after time.Time
+
+func (a after) Match(value driver.Value) bool {
+	at, ok := value.(time.Time)
+	return ok && !at.Before(time.Time(a))
+}
+
+// recorder — Publisher, который запоминает номера опубликованных событий и
+// отвечает ошибкой на события из fail.
+type recorder struct {
+	published []int64
+	fail      map[int64]bool
+}
+
+func (r *recorder) Publish(_ context.Context, event Envelope) error {
+	r.published = append(r.published, event.Sequence)
+	if r.fail[event.Sequence] {
+		return errors.New("subscriber unavailable")
+	}
+	return nil
+}
+
+func TestDispatchOnceKeepsAggregateOrder(t *testing.T) {
+	db, mock := stubDB(t)
+	publisher := &recorder{fail: map[int64]bool{1: true}}
+	dispatcher := NewDispatcher(db, publisher)
+
+	first, second := uuid.New(), uuid.New()
+
+	// Событие 3 того же агрегата, что и 1, не выбирается, пока 1 не доставлено.
+	mock.ExpectBegin()
+	expectBatch(mock, pendingEvent{1, first, 0}, pendingEvent{2, second, 0})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=$1,"last_error"=$2,"next_attempt_at"=$3 WHERE "sequence" = $4`)).
+		WithArgs(1, "subscriber unavailable", sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDelivered(mock, 2, 1)
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	expectBatch(mock, pendingEvent{1, first, 1})
+	expectDelivered(mock, 1, 2)
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	expectBatch(mock, pendingEvent{3, first, 0})
+	expectDelivered(mock, 3, 1)
+	mock.ExpectCommit()
+
+	for i := 0; i < 3; i++ {
+		if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+			t.Fatalf("DispatchOnce: %v", err)
+		}
+		publisher.fail = nil
+	}
+
+	want := []int64{1, 2, 1, 3}
+	if len(publisher.published) != len(want) {
+		t.Fatalf("published = %v, want %v", publisher.published, want)
+	}
+	for i := range want {
+		if publisher.published[i] != want[i] {
+			t.Fatalf("published = %v, want %v", publisher.published, want)
+		}
+	}
+}
+
+func TestDispatchOnceRetriesAfterPublishError(t *testing.T) {
+	db, mock := stubDB(t)
+	publisher := &recorder{fail: map[int64]bool{7: true}}
+	dispatcher := NewDispatcher(db, publisher)
+	aggregateID := uuid.New()
+
+	// Вторая неудачная попытка откладывает следующую на удвоенную задержку.
+	start := time.Now()
+	mock.ExpectBegin()
+	expectBatch(mock, pendingEvent{7, aggregateID, 1})
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=$1,"last_error"=$2,"next_attempt_at"=$3 WHERE "sequence" = $4`)).
+		WithArgs(2, "subscriber unavailable", after(start.Add(2*dispatcher.MinBackoff)), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	processed, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	if processed != 1 {
+		t.Errorf("processed = %d, want 1", processed)
+	}
+
+	// После восстановления подписчика событие доставляется и ошибка очищается.
+	publisher.fail = nil
+	mock.ExpectBegin()
+	expectBatch(mock, pendingEvent{7, aggregateID, 2})
+	expectDelivered(mock, 7, 3)
+	mock.ExpectCommit()
+
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	if len(publisher.published) != 2 {
+		t.Errorf("published = %v, want two attempts", publisher.published)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	dispatcher := &Dispatcher{MinBackoff: 5 * time.Second, MaxBackoff: time.Minute}
+
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{20, time.Minute},
+	}
+	for _, tc := range cases {
+		if got := dispatcher.backoff(tc.attempts); got != tc.want {
+			t.Errorf("backoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// Типы агрегатов, к которым относятся события.
+const (
+	AggregateOrganization = "organization"
+	AggregateDriver       = "driver"
+	AggregateVehicle      = "vehicle"
+)
+
+// Типы доменных событий.
+const (
+	OrganizationCreated     = "OrganizationCreated"
+	OrganizationDeactivated = "OrganizationDeactivated"
+	DriverCreated           = "DriverCreated"
+	DriverUpdated           = "DriverUpdated"
+	DriverDeactivated       = "DriverDeactivated"
+	DriverRehired           = "DriverRehired"
+	VehicleCreated          = "VehicleCreated"
+	VehicleUpdated          = "VehicleUpdated"
+	VehicleDeactivated      = "VehicleDeactivated"
+	VehicleAssigned         = "VehicleAssigned"
+	VehicleUnassigned       = "VehicleUnassigned"
+)
+
+// Types перечисляет все типы событий, которые публикует сервис.
+var Types = []string{
+	OrganizationCreated,
+	OrganizationDeactivated,
+	DriverCreated,
+	DriverUpdated,
+	DriverDeactivated,
+	DriverRehired,
+	VehicleCreated,
+	VehicleUpdated,
+	VehicleDeactivated,
+	VehicleAssigned,
+	VehicleUnassigned,
+}
+
+// IsType проверяет, что тип события известен.
+func IsType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationData — данные организации в событиях.
+type OrganizationData struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	BIN         string     `json:"bin"`
+	ParentOrgID *uuid.UUID `json:"parent_org_id"`
+	IsActive    bool       `json:"is_active"`
+}
+
+// DriverData — данные водителя в событиях. ИИН и телефон не публикуются.
+type DriverData struct {
+	ID           uuid.UUID  `json:"id"`
+	ContractorID *uuid.UUID `json:"contractor_id"`
+	FullName     string     `json:"full_name"`
+	IsActive     bool       `json:"is_active"`
+}
+
+// VehicleData — данные техники в событиях.
+type VehicleData struct {
+	ID           uuid.UUID  `json:"id"`
+	ContractorID *uuid.UUID `json:"contractor_id"`
+	PlateNumber  string     `json:"plate_number"`
+	PlateDisplay string     `json:"plate_display"`
+	Type         string     `json:"type"`
+	DriverID     *uuid.UUID `json:"driver_id"`
+	IsActive     bool       `json:"is_active"`
+}
+
+// VehicleAssignmentData — смена водителя, закреплённого за техникой.
+type VehicleAssignmentData struct {
+	VehicleID        uuid.UUID  `json:"vehicle_id"`
+	ContractorID     *uuid.UUID `json:"contractor_id"`
+	DriverID         *uuid.UUID `json:"driver_id"`
+	PreviousDriverID *uuid.UUID `json:"previous_driver_id"`
+}
+
+// Envelope — событие в том виде, в котором его получают подписчики.
+type Envelope struct {
+	ID             uuid.UUID       `json:"id"`
+	Type           string          `json:"type"`
+	AggregateType  string          `json:"aggregate_type"`
+	AggregateID    uuid.UUID       `json:"aggregate_id"`
+	OrganizationID *uuid.UUID      `json:"organization_id"`
+	Sequence       int64           `json:"sequence"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// ToEnvelope преобразует запись outbox в событие для подписчиков.
+func ToEnvelope(event models.OutboxEvent) Envelope {
+	return Envelope{
+		ID:             event.ID,
+		Type:           event.Type,
+		AggregateType:  event.AggregateType,
+		AggregateID:    event.AggregateID,
+		OrganizationID: event.OrganizationID,
+		Sequence:       event.Sequence,
+		OccurredAt:     event.OccurredAt,
+		Data:           json.RawMessage(event.Payload),
+	}
+}
+
+// Record записывает событие в outbox. tx должен быть транзакцией, в которой
+// выполняется само изменение: событие фиксируется только вместе с ним.
+func Record(tx *gorm.DB, aggregateType string, aggregateID uuid.UUID, orgID *uuid.UUID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		ID:             uuid.New(),
+		AggregateType:  aggregateType,
+		AggregateID:    aggregateID,
+		OrganizationID: orgID,
+		Type:           eventType,
+		Payload:        string(payload),
+		OccurredAt:     now,
+		NextAttemptAt:  now,
+	}).Error
+}
+
+// RecordOrganization записывает событие организации.
+func RecordOrganization(tx *gorm.DB, eventType string, org models.Organization) error {
+	orgID := org.ID
+	return Record(tx, AggregateOrganization, org.ID, &orgID, eventType, OrganizationData{
+		ID:          org.ID,
+		Name:        org.Name,
+		Type:        org.Type,
+		BIN:         org.BIN,
+		ParentOrgID: org.ParentOrgID,
+		IsActive:    org.IsActive,
+	})
+}
+
+// RecordDriver записывает событие водителя.
+func RecordDriver(tx *gorm.DB, eventType string, driver models.Driver) error {
+	return Record(tx, AggregateDriver, driver.ID, driver.ContractorID, eventType, DriverData{
+		ID:           driver.ID,
+		ContractorID: driver.ContractorID,
+		FullName:     driver.FullName,
+		IsActive:     driver.IsActive,
+	})
+}
+
+// RecordVehicle записывает событие техники.
+func RecordVehicle(tx *gorm.DB, eventType string, vehicle models.Vehicle) error {
+	return Record(tx, AggregateVehicle, vehicle.ID, vehicle.ContractorID, eventType, VehicleData{
+		ID:           vehicle.ID,
+		ContractorID: vehicle.ContractorID,
+		PlateNumber:  vehicle.PlateNumber,
+		PlateDisplay: vehicle.PlateDisplay,
+		Type:         vehicle.Type,
+		DriverID:     vehicle.DriverID,
+		IsActive:     vehicle.IsActive,
+	})
+}
+
+// RecordVehicleAssignment записывает VehicleAssigned или VehicleUnassigned, если
+// закреплённый за техникой водитель изменился.
+func RecordVehicleAssignment(tx *gorm.DB, vehicle models.Vehicle, previousDriverID *uuid.UUID) error {
+	if sameID(vehicle.DriverID, previousDriverID) {
+		return nil
+	}
+
+	eventType := VehicleAssigned
+	if vehicle.DriverID == nil {
+		eventType = VehicleUnassigned
+	}
+
+	return Record(tx, AggregateVehicle, vehicle.ID, vehicle.ContractorID, eventType, VehicleAssignmentData{
+		VehicleID:        vehicle.ID,
+		ContractorID:     vehicle.ContractorID,
+		DriverID:         vehicle.DriverID,
+		PreviousDriverID: previousDriverID,
+	})
+}
+
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
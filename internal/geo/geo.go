@@ -0,0 +1,375 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrInvalidPolygon возвращается, если GeoJSON не описывает корректный полигон.
+var ErrInvalidPolygon = errors.New("invalid GeoJSON polygon")
+
+// Point — точка в координатах WGS 84 (долгота, широта), как в GeoJSON.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// Ring — замкнутый контур; последняя точка совпадает с первой.
+type Ring []Point
+
+// Polygon — внешний контур и необязательные отверстия.
+type Polygon struct {
+	Outer Ring
+	Holes []Ring
+}
+
+// BBox — ограничивающий прямоугольник полигона.
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+type geoJSONPolygon struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+// ParsePolygon разбирает геометрию GeoJSON типа Polygon и проверяет контуры.
+func ParsePolygon(raw []byte) (Polygon, error) {
+	var g geoJSONPolygon
+	if err := json.Unmarshal(raw, &g); err != nil {
+		return Polygon{}, ErrInvalidPolygon
+	}
+	if g.Type != "Polygon" || len(g.Coordinates) == 0 {
+		return Polygon{}, ErrInvalidPolygon
+	}
+
+	var p Polygon
+	for i, coords := range g.Coordinates {
+		ring, err := parseRing(coords)
+		if err != nil {
+			return Polygon{}, err
+		}
+		if i == 0 {
+			p.Outer = ring
+			continue
+		}
+		// Отверстие должно лежать внутри внешнего контура и не пересекать другие отверстия.
+		if !(Polygon{Outer: p.Outer}).ContainsPolygon(Polygon{Outer: ring}) {
+			return Polygon{}, ErrInvalidPolygon
+		}
+		for _, hole := range p.Holes {
+			if ringsIntersect(hole, ring) {
+				return Polygon{}, ErrInvalidPolygon
+			}
+		}
+		p.Holes = append(p.Holes, ring)
+	}
+
+	return p, nil
+}
+
+func parseRing(coords [][]float64) (Ring, error) {
+	// Замкнутый контур содержит минимум три различные точки и повтор первой.
+	if len(coords) < 4 {
+		return nil, ErrInvalidPolygon
+	}
+
+	ring := make(Ring, 0, len(coords))
+	for _, c := range coords {
+		if len(c) < 2 {
+			return nil, ErrInvalidPolygon
+		}
+		lon, lat := c[0], c[1]
+		if math.IsNaN(lon) || math.IsNaN(lat) || lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+			return nil, ErrInvalidPolygon
+		}
+		pt := Point{Lon: lon, Lat: lat}
+		// Повторы подряд идущих точек допустимы в GeoJSON и не образуют рёбер.
+		if len(ring) > 0 && ring[len(ring)-1] == pt {
+			continue
+		}
+		ring = append(ring, pt)
+	}
+
+	if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+		return nil, ErrInvalidPolygon
+	}
+	if math.Abs(ring.signedArea()) == 0 || ring.selfIntersects() {
+		return nil, ErrInvalidPolygon
+	}
+
+	return ring, nil
+}
+
+// selfIntersects проверяет, что рёбра контура встречаются только в общих вершинах
+// соседних рёбер: без пересечений, касаний и возвратов вдоль ребра.
+func (r Ring) selfIntersects() bool {
+	n := len(r) - 1
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			a, b, c, d := r[i], r[i+1], r[j], r[j+1]
+			switch {
+			case j == i+1:
+				// Соседние рёбра a-b и b-d не должны накладываться друг на друга.
+				if onSegment(a, b, d) || onSegment(b, d, a) {
+					return true
+				}
+			case i == 0 && j == n-1:
+				// Последнее ребро c-a и первое a-b тоже соседние.
+				if onSegment(a, b, c) || onSegment(c, a, b) {
+					return true
+				}
+			default:
+				if segmentsIntersect(a, b, c, d) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (r Ring) signedArea() float64 {
+	var area float64
+	for i := 0; i < len(r)-1; i++ {
+		area += r[i].Lon*r[i+1].Lat - r[i+1].Lon*r[i].Lat
+	}
+	return area / 2
+}
+
+// BBox возвращает ограничивающий прямоугольник внешнего контура.
+func (p Polygon) BBox() BBox {
+	b := BBox{MinLon: 180, MinLat: 90, MaxLon: -180, MaxLat: -90}
+	for _, pt := range p.Outer {
+		b.MinLon = math.Min(b.MinLon, pt.Lon)
+		b.MinLat = math.Min(b.MinLat, pt.Lat)
+		b.MaxLon = math.Max(b.MaxLon, pt.Lon)
+		b.MaxLat = math.Max(b.MaxLat, pt.Lat)
+	}
+	return b
+}
+
+// ContainsPoint проверяет, лежит ли точка внутри полигона или на его границе.
+func (p Polygon) ContainsPoint(pt Point) bool {
+	if !p.Outer.covers(pt) {
+		return false
+	}
+	for _, hole := range p.Holes {
+		if hole.covers(pt) && !hole.onBoundary(pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsPolygon проверяет, что полигон inner целиком лежит внутри p: все его
+// вершины покрыты p, и рёбра не пересекают границы p.
+func (p Polygon) ContainsPolygon(inner Polygon) bool {
+	for _, pt := range inner.Outer {
+		if !p.ContainsPoint(pt) {
+			return false
+		}
+	}
+
+	boundaries := append([]Ring{p.Outer}, p.Holes...)
+	for i := 0; i < len(inner.Outer)-1; i++ {
+		a, b := inner.Outer[i], inner.Outer[i+1]
+		for _, ring := range boundaries {
+			for j := 0; j < len(ring)-1; j++ {
+				if properlyIntersect(a, b, ring[j], ring[j+1]) {
+					return false
+				}
+			}
+		}
+	}
+
+	// Отверстие p, целиком попавшее внутрь inner, тоже нарушает вложенность.
+	for _, hole := range p.Holes {
+		if inner.Outer.covers(hole[0]) && !inner.Outer.onBoundary(hole[0]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CoveredBy проверяет, что полигон p целиком покрыт объединением полигонов parts.
+// Границы всех полигонов делят плоскость на грани; у каждой грани внутри p
+// проверяются точки по обе стороны от каждого отрезка её границы.
+func (p Polygon) CoveredBy(parts []Polygon) bool {
+	var edges [][2]Point
+	for _, ring := range p.rings() {
+		edges = append(edges, ring.edges()...)
+	}
+	for _, part := range parts {
+		for _, ring := range part.rings() {
+			edges = append(edges, ring.edges()...)
+		}
+	}
+
+	for _, e := range edges {
+		a, b := e[0], e[1]
+		splits := []float64{0, 1}
+		for _, other := range edges {
+			splits = append(splits, splitParams(a, b, other[0], other[1])...)
+		}
+		sort.Float64s(splits)
+
+		dx, dy := b.Lon-a.Lon, b.Lat-a.Lat
+		length := math.Hypot(dx, dy)
+		for k := 0; k < len(splits)-1; k++ {
+			t0, t1 := splits[k], splits[k+1]
+			if t1-t0 < epsilon {
+				continue
+			}
+			t := (t0 + t1) / 2
+			mid := Point{Lon: a.Lon + dx*t, Lat: a.Lat + dy*t}
+			offset := math.Min(length*(t1-t0)*1e-3, 1e-7) / length
+			for _, side := range []float64{offset, -offset} {
+				pt := Point{Lon: mid.Lon - dy*side, Lat: mid.Lat + dx*side}
+				if p.interior(pt) && !anyContains(parts, pt) {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func (p Polygon) rings() []Ring {
+	return append([]Ring{p.Outer}, p.Holes...)
+}
+
+// interior проверяет, что точка лежит внутри полигона, но не на его границе.
+func (p Polygon) interior(pt Point) bool {
+	if !p.ContainsPoint(pt) {
+		return false
+	}
+	for _, ring := range p.rings() {
+		if ring.onBoundary(pt) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyContains(parts []Polygon, pt Point) bool {
+	for _, part := range parts {
+		if part.ContainsPoint(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Ring) edges() [][2]Point {
+	edges := make([][2]Point, 0, len(r)-1)
+	for i := 0; i < len(r)-1; i++ {
+		edges = append(edges, [2]Point{r[i], r[i+1]})
+	}
+	return edges
+}
+
+// splitParams возвращает параметры t на отрезке a-b (0 < t < 1), в которых его
+// пересекает или касается отрезок c-d.
+func splitParams(a, b, c, d Point) []float64 {
+	dx, dy := b.Lon-a.Lon, b.Lat-a.Lat
+	denom := dx*(d.Lat-c.Lat) - dy*(d.Lon-c.Lon)
+	project := func(pt Point) float64 {
+		return ((pt.Lon-a.Lon)*dx + (pt.Lat-a.Lat)*dy) / (dx*dx + dy*dy)
+	}
+
+	var params []float64
+	if math.Abs(denom) < epsilon {
+		// Параллельные отрезки делят a-b только концами c-d, лежащими на нём.
+		for _, pt := range []Point{c, d} {
+			if onSegment(a, b, pt) {
+				params = append(params, project(pt))
+			}
+		}
+	} else if segmentsIntersect(a, b, c, d) {
+		params = append(params, ((c.Lon-a.Lon)*(d.Lat-c.Lat)-(c.Lat-a.Lat)*(d.Lon-c.Lon))/denom)
+	}
+
+	var inside []float64
+	for _, t := range params {
+		if t > 0 && t < 1 {
+			inside = append(inside, t)
+		}
+	}
+	return inside
+}
+
+// ringsIntersect проверяет, есть ли у двух контуров общие точки.
+func ringsIntersect(r, other Ring) bool {
+	for _, e := range r.edges() {
+		for _, o := range other.edges() {
+			if segmentsIntersect(e[0], e[1], o[0], o[1]) {
+				return true
+			}
+		}
+	}
+	return r.covers(other[0]) || other.covers(r[0])
+}
+
+// covers реализует проверку по лучу; точки на границе считаются покрытыми.
+func (r Ring) covers(pt Point) bool {
+	if r.onBoundary(pt) {
+		return true
+	}
+
+	inside := false
+	for i, j := 0, len(r)-2; i < len(r)-1; j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (r Ring) onBoundary(pt Point) bool {
+	for i := 0; i < len(r)-1; i++ {
+		if onSegment(r[i], r[i+1], pt) {
+			return true
+		}
+	}
+	return false
+}
+
+const epsilon = 1e-12
+
+func cross(o, a, b Point) float64 {
+	return (a.Lon-o.Lon)*(b.Lat-o.Lat) - (a.Lat-o.Lat)*(b.Lon-o.Lon)
+}
+
+func onSegment(a, b, pt Point) bool {
+	if math.Abs(cross(a, b, pt)) > epsilon {
+		return false
+	}
+	return pt.Lon >= math.Min(a.Lon, b.Lon)-epsilon && pt.Lon <= math.Max(a.Lon, b.Lon)+epsilon &&
+		pt.Lat >= math.Min(a.Lat, b.Lat)-epsilon && pt.Lat <= math.Max(a.Lat, b.Lat)+epsilon
+}
+
+// segmentsIntersect проверяет, есть ли у отрезков общая точка, включая касания.
+func segmentsIntersect(a, b, c, d Point) bool {
+	if properlyIntersect(a, b, c, d) {
+		return true
+	}
+	return onSegment(c, d, a) || onSegment(c, d, b) || onSegment(a, b, c) || onSegment(a, b, d)
+}
+
+// properlyIntersect проверяет пересечение отрезков во внутренней точке обоих;
+// касания вершинами и наложения вдоль границы пересечением не считаются.
+func properlyIntersect(a, b, c, d Point) bool {
+	d1 := cross(c, d, a)
+	d2 := cross(c, d, b)
+	d3 := cross(a, b, c)
+	d4 := cross(a, b, d)
+	return ((d1 > epsilon && d2 < -epsilon) || (d1 < -epsilon && d2 > epsilon)) &&
+		((d3 > epsilon && d4 < -epsilon) || (d3 < -epsilon && d4 > epsilon))
+}
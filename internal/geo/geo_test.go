@@ -0,0 +1,70 @@
+package geo
+
+import "testing"
+
+func square(minLon, minLat, maxLon, maxLat float64) Polygon {
+	return Polygon{Outer: Ring{
+		{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat},
+	}}
+}
+
+func TestParsePolygonRejectsInvalidRings(t *testing.T) {
+	cases := []struct {
+		name  string
+		raw   string
+		valid bool
+	}{
+		{"square", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`, true},
+		{"repeated vertex", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,0],[1,1],[0,1],[0,0]]]}`, true},
+		{"bow tie", `{"type":"Polygon","coordinates":[[[0,0],[1,1],[1,0],[0,1],[0,0]]]}`, false},
+		{"touching itself", `{"type":"Polygon","coordinates":[[[0,0],[2,0],[1,1],[2,2],[0,2],[1,1],[0,0]]]}`, false},
+		{"spike", `{"type":"Polygon","coordinates":[[[0,0],[2,0],[1,0],[1,1],[0,0]]]}`, false},
+		{"collinear", `{"type":"Polygon","coordinates":[[[0,0],[1,0],[2,0],[0,0]]]}`, false},
+		{"hole inside", `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,1],[2,1],[2,2],[1,2],[1,1]]]}`, true},
+		{"hole outside", `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[5,5],[6,5],[6,6],[5,6],[5,5]]]}`, false},
+		{"overlapping holes", `{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,1],[3,1],[3,3],[1,3],[1,1]],[[2,2],[3.5,2],[3.5,3.5],[2,3.5],[2,2]]]}`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePolygon([]byte(tc.raw))
+			if (err == nil) != tc.valid {
+				t.Errorf("ParsePolygon error = %v, want valid = %v", err, tc.valid)
+			}
+		})
+	}
+}
+
+func TestCoveredBy(t *testing.T) {
+	west := square(0, 0, 2, 2)
+	east := square(2, 0, 4, 2)
+	withHole := Polygon{
+		Outer: square(0, 0, 4, 4).Outer,
+		Holes: []Ring{square(1, 1, 2, 2).Outer},
+	}
+
+	cases := []struct {
+		name  string
+		inner Polygon
+		parts []Polygon
+		want  bool
+	}{
+		{"inside one area", square(0.5, 0.5, 1.5, 1.5), []Polygon{west, east}, true},
+		{"same as area", west, []Polygon{west}, true},
+		{"spans adjacent areas", square(1, 0.5, 3, 1.5), []Polygon{west, east}, true},
+		{"spans areas with a gap", square(1, 0.5, 3, 1.5), []Polygon{west, square(2.5, 0, 4, 2)}, false},
+		{"sticks out", square(1, 1, 3, 3), []Polygon{west, east}, false},
+		{"no areas", west, nil, false},
+		{"covers a hole", square(0.5, 0.5, 2.5, 2.5), []Polygon{withHole}, false},
+		{"beside a hole", square(2.5, 2.5, 3.5, 3.5), []Polygon{withHole}, true},
+		{"hole filled by another area", square(0.5, 0.5, 2.5, 2.5), []Polygon{withHole, square(1, 1, 2, 2)}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.inner.CoveredBy(tc.parts); got != tc.want {
+				t.Errorf("CoveredBy = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/middleware"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/openapi"
+	"github.com/MSTimX/Snowops-roles/internal/pass"
+)
+
+const apiBase = "/api/v1"
+
+// contractEnv — маршруты API и спецификация, по которой проверяются ответы.
+type contractEnv struct {
+	router *gin.Engine
+	spec   *openapi3.T
+	routes routers.Router
+}
+
+func newContractEnv(t *testing.T) *contractEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	raw, err := openapi.JSON()
+	if err != nil {
+		t.Fatalf("openapi.JSON: %v", err)
+	}
+	spec, err := openapi3.NewLoader().LoadFromData(raw)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	// Серверы спецификации относительные; для сопоставления запросов достаточно базового пути.
+	spec.Servers = openapi3.Servers{{URL: "http://example.test" + apiBase}}
+	routes, err := legacy.NewRouter(spec)
+	if err != nil {
+		t.Fatalf("spec router: %v", err)
+	}
+
+	if pass.Default == nil {
+		signer, err := pass.NewSigner(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+		if err != nil {
+			t.Fatalf("pass signer: %v", err)
+		}
+		pass.Default = signer
+	}
+
+	router := gin.New()
+	RegisterPublicRoutes(router.Group(apiBase + "/public"))
+	api := router.Group(apiBase)
+	api.Use(middleware.MockAuthMiddleware())
+	RegisterRoutes(api)
+
+	return &contractEnv{router: router, spec: spec, routes: routes}
+}
+
+// identity — заголовки фиктивной аутентификации для роли.
+func identity(role string) http.Header {
+	header := http.Header{}
+	header.Set("X-User-ID", uuid.NewString())
+	header.Set("X-User-Role", role)
+	header.Set("X-Org-ID", uuid.NewString())
+	return header
+}
+
+// do выполняет запрос и проверяет статус и тело ответа по спецификации:
+// статус должен быть описан у операции, тело — соответствовать схеме.
+func (e *contractEnv) do(t *testing.T, method, path string, header http.Header, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, "http://example.test"+apiBase+path, reader)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+
+	route, pathParams, err := e.routes.FindRoute(req)
+	if err != nil {
+		t.Fatalf("%s %s: not in spec: %v", method, path, err)
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: w.Code,
+		Header: w.Header(),
+		Body:   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	}
+	if err := openapi3filter.ValidateResponse(context.Background(), input); err != nil {
+		t.Errorf("%s %s: response %d does not match spec: %v\nbody: %s", method, path, w.Code, err, w.Body)
+	}
+	return w
+}
+
+// TestRoutesMatchSpec проверяет, что каждый зарегистрированный маршрут описан
+// в спецификации и в спецификации нет маршрутов, которых нет в API.
+func TestRoutesMatchSpec(t *testing.T) {
+	env := newContractEnv(t)
+
+	registered := map[string]bool{}
+	for _, route := range env.router.Routes() {
+		path := strings.TrimPrefix(route.Path, apiBase)
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		registered[route.Method+" "+strings.Join(segments, "/")] = true
+	}
+
+	documented := map[string]bool{}
+	for path, item := range env.spec.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	var missing, extra []string
+	for key := range registered {
+		if !documented[key] {
+			missing = append(missing, key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	if len(missing) > 0 {
+		t.Errorf("routes missing from spec: %v", missing)
+	}
+	if len(extra) > 0 {
+		t.Errorf("spec paths without routes: %v", extra)
+	}
+}
+
+// TestResponsesMatchSpec проверяет ответы обработчиков, не требующие данных
+// в базе: справочники, открытые маршруты, ошибки аутентификации, разрешений и
+// проверки запросов.
+func TestResponsesMatchSpec(t *testing.T) {
+	env := newContractEnv(t)
+
+	akimat := identity(models.RoleAkimatAdmin)
+	too := identity(models.RoleTooAdmin)
+	contractor := identity(models.RoleContractorAdmin)
+	kazakh := identity(models.RoleAkimatAdmin)
+	kazakh.Set("Accept-Language", "kk")
+
+	token, err := pass.Default.Sign(pass.Claims{
+		PassID:     uuid.New(),
+		DriverID:   uuid.New(),
+		VehicleID:  uuid.New(),
+		DriverName: "Иванов Иван",
+		Plate:      "123ABC02",
+		IssuedAt:   time.Now().Unix(),
+		ExpiresAt:  time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("sign pass: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header http.Header
+		body   string
+		status int
+	}{
+		{"vehicle types", http.MethodGet, "/vehicle-types", akimat, "", http.StatusOK},
+		{"vehicle types kk", http.MethodGet, "/vehicle-types", kazakh, "", http.StatusOK},
+		{"vehicle types for contractor", http.MethodGet, "/vehicle-types", contractor, "", http.StatusOK},
+		{"webhooks by contractor", http.MethodGet, "/webhooks", contractor, "", http.StatusForbidden},
+		{"contract check without database", http.MethodPost, "/drivers", contractor, `{}`, http.StatusServiceUnavailable},
+		{"public key", http.MethodGet, "/public/passes/public-key", nil, "", http.StatusOK},
+		{"verify pass without database", http.MethodPost, "/public/passes/verify", nil, `{"token":"` + token + `"}`, http.StatusServiceUnavailable},
+		{"verify forged pass", http.MethodPost, "/public/passes/verify", nil, `{"token":"SP1.e30.AAAA"}`, http.StatusOK},
+		{"verify without token", http.MethodPost, "/public/passes/verify", nil, `{}`, http.StatusBadRequest},
+		{"unauthenticated", http.MethodGet, "/organizations", nil, "", http.StatusUnauthorized},
+		{"unauthenticated kk", http.MethodGet, "/drivers", http.Header{"Accept-Language": {"kk"}}, "", http.StatusUnauthorized},
+		{"invalid organization id", http.MethodGet, "/organizations/not-a-uuid", akimat, "", http.StatusBadRequest},
+		{"organization without name", http.MethodPost, "/organizations", akimat, `{"type":"TOO"}`, http.StatusBadRequest},
+		{"driver by non-contractor", http.MethodPost, "/drivers", akimat, `{"full_name":"Иванов Иван","iin":"900101300123","birth_year":1990,"phone":"+77010000000"}`, http.StatusForbidden},
+		{"vehicle without database", http.MethodGet, "/vehicles/" + uuid.NewString(), akimat, "", http.StatusServiceUnavailable},
+		{"invalid capacity type", http.MethodGet, "/vehicle-capacity?type=SPACESHIP", akimat, "", http.StatusBadRequest},
+		{"invalid contract id", http.MethodGet, "/contracts/42", akimat, "", http.StatusBadRequest},
+		{"contract without fields", http.MethodPost, "/contracts", akimat, `{}`, http.StatusBadRequest},
+		{"invalid shift id", http.MethodGet, "/shifts/42", akimat, "", http.StatusBadRequest},
+		{"invalid service area id", http.MethodGet, "/service-areas/42", akimat, "", http.StatusBadRequest},
+		{"webhooks by too", http.MethodGet, "/webhooks", too, "", http.StatusForbidden},
+		{"webhooks without database", http.MethodGet, "/webhooks", akimat, "", http.StatusServiceUnavailable},
+		{"service account without scopes", http.MethodPost, "/service-accounts", akimat, `{"name":"gps"}`, http.StatusBadRequest},
+		{"service account with unknown scope", http.MethodPost, "/service-accounts", akimat, `{"name":"gps","scopes":["root"]}`, http.StatusBadRequest},
+		{"invalid service account id", http.MethodGet, "/service-accounts/42", akimat, "", http.StatusBadRequest},
+		{"user lookup without phone", http.MethodGet, "/users", akimat, "", http.StatusBadRequest},
+		{"get user", http.MethodGet, "/users/" + uuid.NewString(), akimat, "", http.StatusNotImplemented},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := env.do(t, tc.method, tc.path, tc.header, tc.body)
+			if w.Code != tc.status {
+				t.Errorf("status = %d, want %d; body: %s", w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
+
+// TestServiceAccountScopesMatchSpec проверяет ответы для служебной учётной
+// записи: разрешённое чтение справочников и отказ FORBIDDEN_SCOPE.
+func TestServiceAccountScopesMatchSpec(t *testing.T) {
+	env := newContractEnv(t)
+
+	accountID := uuid.New()
+	principal := &auth.Principal{
+		ServiceAccountID: &accountID,
+		Role:             models.RoleTooAdmin,
+		OrganizationID:   uuid.New(),
+		Scopes:           []string{models.ScopeDriversRead},
+	}
+	router := gin.New()
+	api := router.Group(apiBase)
+	api.Use(func(c *gin.Context) { auth.SetGin(c, principal) })
+	RegisterRoutes(api)
+	env.router = router
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"reference data", http.MethodGet, "/vehicle-types", "", http.StatusOK},
+		{"write without scope", http.MethodPost, "/drivers", `{}`, http.StatusForbidden},
+		{"read without scope", http.MethodGet, "/vehicles", "", http.StatusForbidden},
+		{"profile", http.MethodGet, "/me", "", http.StatusForbidden},
+		{"service accounts", http.MethodGet, "/service-accounts", "", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := env.do(t, tc.method, tc.path, nil, tc.body)
+			if w.Code != tc.status {
+				t.Errorf("status = %d, want %d; body: %s", w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// ContractRequest описывает договор. Заказчиком всегда выступает организация
+// текущего пользователя.
+type ContractRequest struct {
+	Number          string    `json:"number" binding:"required"`
+	ContractorOrgID uuid.UUID `json:"contractor_org_id" binding:"required"`
+	StartDate       string    `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate         string    `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Districts       []string  `json:"districts"`
+	MaxVehicles     int       `json:"max_vehicles" binding:"gte=0"`
+	Status          string    `json:"status" binding:"omitempty,oneof=DRAFT ACTIVE SUSPENDED TERMINATED"`
+}
+
+// ContractDTO — представление договора в ответах API.
+type ContractDTO struct {
+	ID                uuid.UUID `json:"id"`
+	Number            string    `json:"number"`
+	CustomerOrgID     uuid.UUID `json:"customer_org_id"`
+	ContractorOrgID   uuid.UUID `json:"contractor_org_id"`
+	CustomerOrgName   string    `json:"customer_org_name,omitempty"`
+	ContractorOrgName string    `json:"contractor_org_name,omitempty"`
+	StartDate         string    `json:"start_date"`
+	EndDate           string    `json:"end_date"`
+	Districts         []string  `json:"districts"`
+	MaxVehicles       int       `json:"max_vehicles"`
+	Status            string    `json:"status"`
+	InForce           bool      `json:"in_force"`
+	DaysUntilExpiry   int       `json:"days_until_expiry"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func toContractDTO(contract models.Contract, now time.Time) ContractDTO {
+	dto := ContractDTO{
+		ID:              contract.ID,
+		Number:          contract.Number,
+		CustomerOrgID:   contract.CustomerOrgID,
+		ContractorOrgID: contract.ContractorOrgID,
+		StartDate:       contract.StartDate.Format(dateLayout),
+		EndDate:         contract.EndDate.Format(dateLayout),
+		Districts:       contract.Districts,
+		MaxVehicles:     contract.MaxVehicles,
+		Status:          contract.Status,
+		InForce:         contract.InForce(now),
+		DaysUntilExpiry: daysUntil(now, contract.EndDate),
+		CreatedAt:       contract.CreatedAt,
+		UpdatedAt:       contract.UpdatedAt,
+	}
+	if dto.Districts == nil {
+		dto.Districts = []string{}
+	}
+	if contract.CustomerOrg != nil {
+		dto.CustomerOrgName = contract.CustomerOrg.Name
+	}
+	if contract.ContractorOrg != nil {
+		dto.ContractorOrgName = contract.ContractorOrg.Name
+	}
+	return dto
+}
+
+// findContractInForce возвращает действующий на дату now договор организации-исполнителя
+// с наиболее поздней датой окончания или nil, если такого договора нет.
+func findContractInForce(db *gorm.DB, contractorOrgID uuid.UUID, now time.Time) (*models.Contract, error) {
+	today := models.Today(now)
+
+	var contract models.Contract
+	err := db.Where("contractor_org_id = ? AND status = ? AND start_date <= ? AND end_date >= ?",
+		contractorOrgID, models.ContractStatusActive, today, today).
+		Order("end_date DESC").
+		First(&contract).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// requireContractInForce проверяет наличие действующего договора у подрядчика.
+func requireContractInForce(db *gorm.DB, contractorOrgID uuid.UUID) (*models.Contract, *apierror.Error) {
+	contract, err := findContractInForce(db, contractorOrgID, time.Now())
+	if err != nil {
+		return nil, apierror.Internal("failed to fetch contract", err)
+	}
+	if contract == nil {
+		return nil, apierror.New(apierror.CodeContractRequired)
+	}
+	return contract, nil
+}
+
+// checkContractVehicleLimit проверяет, что у подрядчика есть действующий договор и
+// добавление ещё одной единицы техники не превысит допустимое договором количество.
+func checkContractVehicleLimit(db *gorm.DB, contractorID uuid.UUID) *apierror.Error {
+	contract, apiErr := requireContractInForce(db, contractorID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if contract.MaxVehicles == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.Vehicle{}).
+		Where("contractor_id = ? AND is_active = ?", contractorID, true).
+		Count(&count).Error; err != nil {
+		return apierror.Internal("failed to count vehicles", err)
+	}
+	if count >= int64(contract.MaxVehicles) {
+		return apierror.New(apierror.CodeContractVehicleLimit).With("max_vehicles", contract.MaxVehicles)
+	}
+
+	return nil
+}
+
+// RequireActiveContract блокирует запросы пользователей подрядчика, у которого нет
+// действующего на сегодня договора с ТОО. Остальные роли пропускаются без проверки.
+// Подключается к маршрутам организаций и приёма водителей и техники.
+func RequireActiveContract() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.Require(c)
+		if !ok {
+			return
+		}
+		if principal.Role != models.RoleContractorAdmin && principal.Role != models.RoleDriver {
+			c.Next()
+			return
+		}
+		orgID := principal.OrganizationID
+
+		if database.DB == nil {
+			apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+			return
+		}
+
+		if _, apiErr := requireContractInForce(database.WithContext(c.Request.Context()), orgID); apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// checkContractCounterparty проверяет, что роль может заключить договор с организацией:
+// акимат — с активным ТОО, ТОО — с собственным активным подрядчиком.
+func checkContractCounterparty(db *gorm.DB, role string, currentOrgID, contractorOrgID uuid.UUID) *apierror.Error {
+	var org models.Organization
+	if err := db.Where("id = ? AND is_active = ?", contractorOrgID, true).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.New(apierror.CodeOrgNotFound)
+		}
+		return apierror.Internal("failed to fetch organization", err)
+	}
+
+	switch role {
+	case models.RoleAkimatAdmin:
+		if org.Type == models.OrgTypeToo {
+			return nil
+		}
+	case models.RoleTooAdmin:
+		if org.Type == models.OrgTypeContractor && org.ParentOrgID != nil && *org.ParentOrgID == currentOrgID {
+			return nil
+		}
+	default:
+		return apierror.New(apierror.CodeForbidden)
+	}
+
+	return apierror.New(apierror.CodeValidationFailed).
+		WithMessage("contract can only be signed with a TOO or its own contractor")
+}
+
+// parseContractRequest проверяет период и статус договора.
+func parseContractRequest(req *ContractRequest) (time.Time, time.Time, *apierror.Error) {
+	if req.Status == "" {
+		req.Status = models.ContractStatusActive
+	}
+
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "start_date", Rule: "datetime", Param: dateLayout})
+	}
+
+	end, err := time.Parse(dateLayout, req.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "end_date", Rule: "datetime", Param: dateLayout})
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "end_date", Rule: "gtfield", Param: "start_date"})
+	}
+
+	return start, end, nil
+}
+
+// contractVisibility ограничивает запрос договорами, видимыми роли: акимат видит все,
+// ТОО — свои договоры с акиматом и с подрядчиками, подрядчик — только свои.
+func contractVisibility(q *gorm.DB, role string, currentOrgID uuid.UUID) (*gorm.DB, *apierror.Error) {
+	switch role {
+	case models.RoleAkimatAdmin:
+		return q, nil
+	case models.RoleTooAdmin:
+		return q.Where("contracts.customer_org_id = ? OR contracts.contractor_org_id = ?", currentOrgID, currentOrgID), nil
+	case models.RoleContractorAdmin:
+		return q.Where("contracts.contractor_org_id = ?", currentOrgID), nil
+	default:
+		return nil, apierror.New(apierror.CodeForbidden)
+	}
+}
+
+// loadAccessibleContract загружает договор из параметра :id в пределах видимости роли.
+// При manage = true дополнительно требуется, чтобы организация пользователя была заказчиком.
+func loadAccessibleContract(c *gin.Context, manage bool) (*models.Contract, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	contractUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid contract id"))
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	q, apiErr := contractVisibility(database.WithContext(c.Request.Context()).Model(&models.Contract{}), role, currentOrgUUID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return nil, false
+	}
+
+	var contract models.Contract
+	if err := q.Where("contracts.id = ?", contractUUID).First(&contract).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeContractNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch contract", err))
+		}
+		return nil, false
+	}
+
+	if manage && contract.CustomerOrgID != currentOrgUUID {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return nil, false
+	}
+
+	return &contract, true
+}
+
+func respondContractDuplicate(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).
+			WithMessage("%s with this %s already exists", i18n.Label("contract"), i18n.Label("number")))
+		return
+	}
+	apierror.Respond(c, apierror.Internal(message, err))
+}
+
+func ListContracts(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	q, apiErr := contractVisibility(database.WithContext(c.Request.Context()).Model(&models.Contract{}), role, currentOrgUUID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	if raw := c.Query("contractor_org_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid organization id"))
+			return
+		}
+		q = q.Where("contracts.contractor_org_id = ?", id)
+	}
+	if status := c.Query("status"); status != "" {
+		if !models.IsContractStatus(status) {
+			apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "status", Rule: "oneof", Param: "DRAFT ACTIVE SUSPENDED TERMINATED"}))
+			return
+		}
+		q = q.Where("contracts.status = ?", status)
+	}
+
+	var contracts []models.Contract
+	if err := q.Preload("CustomerOrg").Preload("ContractorOrg").Order("contracts.end_date").Find(&contracts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch contracts", err))
+		return
+	}
+
+	now := time.Now()
+	result := make([]ContractDTO, 0, len(contracts))
+	for _, contract := range contracts {
+		result = append(result, toContractDTO(contract, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contracts": result})
+}
+
+func CreateContract(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if role != models.RoleAkimatAdmin && role != models.RoleTooAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	var req ContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	start, end, apiErr := parseContractRequest(&req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	if apiErr := checkContractCounterparty(database.WithContext(c.Request.Context()), role, currentOrgUUID, req.ContractorOrgID); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	contract := models.Contract{
+		Number:          req.Number,
+		CustomerOrgID:   currentOrgUUID,
+		ContractorOrgID: req.ContractorOrgID,
+		StartDate:       start,
+		EndDate:         end,
+		Districts:       req.Districts,
+		MaxVehicles:     req.MaxVehicles,
+		Status:          req.Status,
+	}
+
+	if err := database.WithContext(c.Request.Context()).Create(&contract).Error; err != nil {
+		respondContractDuplicate(c, err, "failed to create contract")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"contract": toContractDTO(contract, time.Now())})
+}
+
+func GetContract(c *gin.Context) {
+	contract, ok := loadAccessibleContract(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contract": toContractDTO(*contract, time.Now())})
+}
+
+func UpdateContract(c *gin.Context) {
+	contract, ok := loadAccessibleContract(c, true)
+	if !ok {
+		return
+	}
+
+	var req ContractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	start, end, apiErr := parseContractRequest(&req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	// Подрядчика договора сменить нельзя: для другого подрядчика заключается новый договор.
+	if req.ContractorOrgID != contract.ContractorOrgID {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "contractor_org_id", Rule: "eq", Param: contract.ContractorOrgID.String()}))
+		return
+	}
+
+	// Обновление через структуру, чтобы список районов прошёл через JSON-сериализатор;
+	// Select нужен для записи нулевых значений.
+	if err := database.WithContext(c.Request.Context()).Model(contract).
+		Select("number", "start_date", "end_date", "districts", "max_vehicles", "status").
+		Updates(models.Contract{
+			Number:      req.Number,
+			StartDate:   start,
+			EndDate:     end,
+			Districts:   req.Districts,
+			MaxVehicles: req.MaxVehicles,
+			Status:      req.Status,
+		}).Error; err != nil {
+		respondContractDuplicate(c, err, "failed to update contract")
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", contract.ID).First(contract).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch contract", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contract": toContractDTO(*contract, time.Now())})
+}
+
+// DeleteContract расторгает договор; запись сохраняется для истории.
+func DeleteContract(c *gin.Context) {
+	contract, ok := loadAccessibleContract(c, true)
+	if !ok {
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(contract).Update("status", models.ContractStatusTerminated).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to terminate contract", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListExpiringContracts возвращает активные договоры, истекающие в ближайшие дни,
+// и (по запросу) уже истёкшие, но не закрытые договоры.
+func ListExpiringContracts(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	days, _, includeExpired, apiErr := parseExpiringQuery(c, false)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	q, apiErr := contractVisibility(database.WithContext(c.Request.Context()).Model(&models.Contract{}), role, currentOrgUUID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	now := time.Now()
+	today := models.Today(now)
+	until := today.AddDate(0, 0, days)
+
+	q = q.Where("contracts.status = ? AND contracts.end_date <= ?", models.ContractStatusActive, until)
+	if !includeExpired {
+		q = q.Where("contracts.end_date >= ?", today)
+	}
+
+	var contracts []models.Contract
+	if err := q.Preload("CustomerOrg").Preload("ContractorOrg").Order("contracts.end_date").Find(&contracts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch expiring contracts", err))
+		return
+	}
+
+	result := make([]ContractDTO, 0, len(contracts))
+	for _, contract := range contracts {
+		result = append(result, toContractDTO(contract, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "contracts": result})
+}
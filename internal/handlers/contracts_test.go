@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// TestFindContractInForceUsesServiceDate проверяет, что договор ищется по дате в
+// часовом поясе сервиса: около полуночи в Алматы дата в UTC ещё предыдущая.
+func TestFindContractInForceUsesServiceDate(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Almaty")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	previous := models.Location
+	models.Location = location
+	t.Cleanup(func() { models.Location = previous })
+
+	march31 := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	april1 := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		now   time.Time
+		today time.Time
+		found bool
+	}{
+		// Договор с датой начала 1 апреля уже действует.
+		{"start date", time.Date(2026, 3, 31, 19, 30, 0, 0, time.UTC), april1, true},
+		// Договор с датой окончания 31 марта ещё действует.
+		{"end date", time.Date(2026, 3, 31, 17, 30, 0, 0, time.UTC), march31, true},
+		{"no contract", time.Date(2026, 3, 31, 19, 30, 0, 0, time.UTC), april1, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := stubDB(t)
+			contractorID := uuid.New()
+
+			result := sqlmock.NewRows(nil)
+			if tc.found {
+				result = rows("id", uuid.New(), "status", models.ContractStatusActive,
+					"start_date", tc.today, "end_date", tc.today)
+			}
+			mock.ExpectQuery(`SELECT * FROM contracts WHERE contractor_org_id = $1 AND status = $2 AND start_date <= $3 AND end_date >= $4`).
+				WithArgs(contractorID, models.ContractStatusActive, tc.today, tc.today, 1).
+				WillReturnRows(result)
+
+			contract, err := findContractInForce(database.DB, contractorID, tc.now)
+			if err != nil {
+				t.Fatalf("findContractInForce: %v", err)
+			}
+			if (contract != nil) != tc.found {
+				t.Errorf("contract = %v, want found %v", contract, tc.found)
+			}
+		})
+	}
+}
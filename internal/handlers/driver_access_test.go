@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// TestDriverOfForeignTooIsForbidden проверяет, что администратор ТОО не видит
+// водителя и его документы, если подрядчик водителя принадлежит другому ТОО.
+func TestDriverOfForeignTooIsForbidden(t *testing.T) {
+	env := newContractEnv(t)
+	now := time.Now()
+
+	for _, path := range []string{"", "/documents"} {
+		t.Run("GET /drivers/{id}"+path, func(t *testing.T) {
+			mock := stubDB(t)
+			tooID := uuid.New()
+			driverID := uuid.New()
+			contractorID := uuid.New()
+			mock.ExpectQuery(`SELECT * FROM drivers WHERE id = $1`).
+				WillReturnRows(rows("id", driverID, "contractor_id", contractorID, "full_name", "Иванов Иван",
+					"is_active", true, "created_at", now, "updated_at", now))
+			mock.ExpectQuery(`SELECT count(*) FROM organizations WHERE id = $1 AND parent_org_id = $2`).
+				WithArgs(contractorID, tooID).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+			header := identity(models.RoleTooAdmin)
+			header.Set("X-Org-ID", tooID.String())
+			w := env.do(t, http.MethodGet, "/drivers/"+driverID.String()+path, header, "")
+			if w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403; body: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// dateLayout — формат дат документов в запросах и ответах.
+const dateLayout = "2006-01-02"
+
+// Ограничения запроса истекающих документов.
+const (
+	defaultExpiringDays = 30
+	maxExpiringDays     = 365
+)
+
+type DriverDocumentRequest struct {
+	Type      string `json:"type" binding:"required,oneof=DRIVER_LICENSE MEDICAL_CERTIFICATE"`
+	Number    string `json:"number" binding:"required"`
+	Category  string `json:"category"`
+	IssuedAt  string `json:"issued_at" binding:"required,datetime=2006-01-02"`
+	ExpiresAt string `json:"expires_at" binding:"required,datetime=2006-01-02"`
+	FileRef   string `json:"file_ref"`
+}
+
+// DriverDocumentDTO — представление документа водителя в ответах API.
+type DriverDocumentDTO struct {
+	ID              uuid.UUID `json:"id"`
+	DriverID        uuid.UUID `json:"driver_id"`
+	Type            string    `json:"type"`
+	Number          string    `json:"number"`
+	Category        string    `json:"category"`
+	IssuedAt        string    `json:"issued_at"`
+	ExpiresAt       string    `json:"expires_at"`
+	FileRef         string    `json:"file_ref"`
+	IsExpired       bool      `json:"is_expired"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ExpiringDriverDocumentDTO дополняет документ сведениями о водителе для отчёта об истечении.
+type ExpiringDriverDocumentDTO struct {
+	DriverDocumentDTO
+	DriverFullName string     `json:"driver_full_name"`
+	ContractorID   *uuid.UUID `json:"contractor_id"`
+}
+
+func toDriverDocumentDTO(doc models.DriverDocument, now time.Time) DriverDocumentDTO {
+	return DriverDocumentDTO{
+		ID:              doc.ID,
+		DriverID:        doc.DriverID,
+		Type:            doc.Type,
+		Number:          doc.Number,
+		Category:        doc.Category,
+		IssuedAt:        doc.IssuedAt.Format(dateLayout),
+		ExpiresAt:       doc.ExpiresAt.Format(dateLayout),
+		FileRef:         doc.FileRef,
+		IsExpired:       doc.IsExpired(now),
+		DaysUntilExpiry: daysUntil(now, doc.ExpiresAt),
+		CreatedAt:       doc.CreatedAt,
+		UpdatedAt:       doc.UpdatedAt,
+	}
+}
+
+// daysUntil считает число календарных дней от now до date; для прошедших дат результат отрицательный.
+func daysUntil(now, date time.Time) int {
+	from := models.Today(now)
+	to := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// parseDocumentDates проверяет даты выдачи и окончания документа.
+func parseDocumentDates(issuedAt, expiresAt string) (time.Time, time.Time, *apierror.Error) {
+	issued, err := time.Parse(dateLayout, issuedAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "issued_at", Rule: "datetime", Param: dateLayout})
+	}
+
+	expires, err := time.Parse(dateLayout, expiresAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "expires_at", Rule: "datetime", Param: dateLayout})
+	}
+
+	if !expires.After(issued) {
+		return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "expires_at", Rule: "gtfield", Param: "issued_at"})
+	}
+
+	return issued, expires, nil
+}
+
+func validateDriverDocumentRequest(req DriverDocumentRequest) (time.Time, time.Time, *apierror.Error) {
+	if req.Type == models.DriverDocLicense {
+		if req.Category == "" {
+			return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldRequired("category"))
+		}
+		if !models.IsLicenseCategory(req.Category) {
+			return time.Time{}, time.Time{}, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "category", Rule: "oneof", Param: "B C C1 CE C1E D"})
+		}
+	}
+
+	return parseDocumentDates(req.IssuedAt, req.ExpiresAt)
+}
+
+// loadAccessibleDriver загружает водителя из параметра :id и проверяет доступ к нему.
+func loadAccessibleDriver(c *gin.Context) (*models.Driver, bool) {
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	driverUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+		return nil, false
+	}
+
+	var driver models.Driver
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", driverUUID).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("db query failed", err))
+		}
+		return nil, false
+	}
+
+	role, currentOrgID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	if !authorizeDriver(c, role, currentOrgID, driver) {
+		return nil, false
+	}
+
+	return &driver, true
+}
+
+// loadDriverDocument загружает активный документ из параметра :docId для водителя.
+func loadDriverDocument(c *gin.Context, driverID uuid.UUID) (*models.DriverDocument, bool) {
+	docUUID, err := uuid.Parse(c.Param("docId"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid document id"))
+		return nil, false
+	}
+
+	var doc models.DriverDocument
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND driver_id = ? AND is_active = ?", docUUID, driverID, true).First(&doc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeDocumentNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch driver document", err))
+		}
+		return nil, false
+	}
+
+	return &doc, true
+}
+
+// driverClearance загружает документы водителя и вычисляет его допуск к работе.
+func driverClearance(db *gorm.DB, driver models.Driver) (models.Clearance, error) {
+	return driverClearanceFor(db, driver, "")
+}
+
+// driverClearanceFor вычисляет допуск водителя к технике заданного типа.
+func driverClearanceFor(db *gorm.DB, driver models.Driver, vehicleType string) (models.Clearance, error) {
+	var docs []models.DriverDocument
+	if err := db.Where("driver_id = ? AND is_active = ?", driver.ID, true).Find(&docs).Error; err != nil {
+		return models.Clearance{}, err
+	}
+
+	return models.EvaluateDriverClearanceFor(driver, docs, vehicleType, time.Now()), nil
+}
+
+func ListDriverDocuments(c *gin.Context) {
+	driver, ok := loadAccessibleDriver(c)
+	if !ok {
+		return
+	}
+
+	var docs []models.DriverDocument
+	if err := database.WithContext(c.Request.Context()).Where("driver_id = ? AND is_active = ?", driver.ID, true).Order("expires_at").Find(&docs).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch driver documents", err))
+		return
+	}
+
+	now := time.Now()
+	result := make([]DriverDocumentDTO, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, toDriverDocumentDTO(doc, now))
+	}
+
+	clearance := models.EvaluateDriverClearance(*driver, docs, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents":        result,
+		"cleared_for_work": clearance.Cleared,
+		"clearance_issues": clearanceIssues(clearance),
+	})
+}
+
+func CreateDriverDocument(c *gin.Context) {
+	driver, ok := loadAccessibleDriver(c)
+	if !ok {
+		return
+	}
+
+	var req DriverDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	issuedAt, expiresAt, apiErr := validateDriverDocumentRequest(req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	doc := models.DriverDocument{
+		DriverID:  driver.ID,
+		Type:      req.Type,
+		Number:    req.Number,
+		Category:  req.Category,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+		FileRef:   req.FileRef,
+		IsActive:  true,
+	}
+
+	if err := database.WithContext(c.Request.Context()).Create(&doc).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create driver document", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"document": toDriverDocumentDTO(doc, time.Now())})
+}
+
+func GetDriverDocument(c *gin.Context) {
+	driver, ok := loadAccessibleDriver(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadDriverDocument(c, driver.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": toDriverDocumentDTO(*doc, time.Now())})
+}
+
+func UpdateDriverDocument(c *gin.Context) {
+	driver, ok := loadAccessibleDriver(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadDriverDocument(c, driver.ID)
+	if !ok {
+		return
+	}
+
+	var req DriverDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	issuedAt, expiresAt, apiErr := validateDriverDocumentRequest(req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(doc).Updates(map[string]interface{}{
+		"type":       req.Type,
+		"number":     req.Number,
+		"category":   req.Category,
+		"issued_at":  issuedAt,
+		"expires_at": expiresAt,
+		"file_ref":   req.FileRef,
+	}).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to update driver document", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": toDriverDocumentDTO(*doc, time.Now())})
+}
+
+func DeleteDriverDocument(c *gin.Context) {
+	driver, ok := loadAccessibleDriver(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadDriverDocument(c, driver.ID)
+	if !ok {
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(doc).Update("is_active", false).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to delete driver document", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListExpiringDriverDocuments возвращает документы водителей, истекающие в ближайшие
+// days дней, в пределах подрядчиков, доступных текущему пользователю.
+func ListExpiringDriverDocuments(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	days, requested, includeExpired, apiErr := parseExpiringQuery(c, false)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	now := time.Now()
+	today := models.Today(now)
+	until := today.AddDate(0, 0, days)
+
+	q := database.WithContext(c.Request.Context()).Model(&models.DriverDocument{}).
+		Joins("JOIN drivers ON drivers.id = driver_documents.driver_id").
+		Where("driver_documents.is_active = ? AND drivers.is_active = ?", true, true).
+		Where("driver_documents.expires_at <= ?", until)
+	if !includeExpired {
+		q = q.Where("driver_documents.expires_at >= ?", today)
+	}
+	if contractorIDs != nil {
+		q = q.Where("drivers.contractor_id IN ?", contractorIDs)
+	}
+
+	var docs []models.DriverDocument
+	if err := q.Preload("Driver").Order("driver_documents.expires_at").Find(&docs).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch expiring driver documents", err))
+		return
+	}
+
+	result := make([]ExpiringDriverDocumentDTO, 0, len(docs))
+	for _, doc := range docs {
+		item := ExpiringDriverDocumentDTO{DriverDocumentDTO: toDriverDocumentDTO(doc, now)}
+		if doc.Driver != nil {
+			item.DriverFullName = doc.Driver.FullName
+			item.ContractorID = doc.Driver.ContractorID
+		}
+		result = append(result, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "documents": result})
+}
+
+// parseExpiringQuery разбирает общие параметры отчётов об истекающих документах:
+// days, contractor_id и include_expired.
+func parseExpiringQuery(c *gin.Context, includeExpiredByDefault bool) (int, *uuid.UUID, bool, *apierror.Error) {
+	days := defaultExpiringDays
+	if raw := c.Query("days"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 || value > maxExpiringDays {
+			return 0, nil, false, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "days", Rule: "max", Param: strconv.Itoa(maxExpiringDays)})
+		}
+		days = value
+	}
+
+	requested, apiErr := parseContractorQuery(c)
+	if apiErr != nil {
+		return 0, nil, false, apiErr
+	}
+
+	includeExpired := includeExpiredByDefault
+	if raw := c.Query("include_expired"); raw != "" {
+		includeExpired = raw == "true"
+	}
+
+	return days, requested, includeExpired, nil
+}
+
+func clearanceIssues(clearance models.Clearance) []string {
+	if clearance.Issues == nil {
+		return []string{}
+	}
+	return clearance.Issues
+}
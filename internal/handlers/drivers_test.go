@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// TestUpdateDriverPhoneUpdatesLinkedUser проверяет, что новый телефон водителя
+// записывается и в его учётную запись в той же транзакции.
+func TestUpdateDriverPhoneUpdatesLinkedUser(t *testing.T) {
+	env := newContractEnv(t)
+	mock := stubDB(t)
+	now := time.Now()
+
+	contractorID := uuid.New()
+	driverID := uuid.New()
+	const phone = "+77011234567"
+	driverRow := func(phone string) *sqlmock.Rows {
+		return rows("id", driverID, "contractor_id", contractorID, "full_name", "Иванов Иван",
+			"phone", phone, "is_active", true, "created_at", now, "updated_at", now)
+	}
+
+	mock.ExpectQuery(`SELECT * FROM drivers WHERE id = $1`).WillReturnRows(driverRow("+77010000000"))
+	mock.ExpectQuery(`SELECT * FROM drivers WHERE (phone = $1 AND is_active = $2) AND id <> $3`).
+		WithArgs(phone, true, driverID, 1).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery(`SELECT * FROM users WHERE (phone = $1 AND is_active = $2)`).
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE drivers SET phone=$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET phone=$1,updated_at=$2 WHERE driver_id = $3`).
+		WithArgs(phone, sqlmock.AnyArg(), driverID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT * FROM drivers WHERE id = $1`).WillReturnRows(driverRow(phone))
+	mock.ExpectQuery(`INSERT INTO outbox_events`).WillReturnRows(rows("sequence", int64(1)))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT * FROM driver_documents WHERE driver_id = $1 AND is_active = $2`).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	header := identity(models.RoleContractorAdmin)
+	header.Set("X-Org-ID", contractorID.String())
+	w := env.do(t, http.MethodPut, "/drivers/"+driverID.String(), header, `{"phone":"`+phone+`"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200; body: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// OrganizationDTO — представление организации в ответах API.
+type OrganizationDTO struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	BIN          string     `json:"bin"`
+	HeadFullName string     `json:"head_full_name"`
+	Address      string     `json:"address"`
+	Phone        string     `json:"phone"`
+	ParentOrgID  *uuid.UUID `json:"parent_org_id"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// UserDTO — представление пользователя в ответах API. Хеш пароля наружу не отдаётся.
+type UserDTO struct {
+	ID             uuid.UUID  `json:"id"`
+	Phone          string     `json:"phone"`
+	Role           string     `json:"role"`
+	Login          *string    `json:"login"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	DriverID       *uuid.UUID `json:"driver_id"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// DriverDTO — представление водителя в ответах API вместе с допуском к работе.
+type DriverDTO struct {
+	ID              uuid.UUID  `json:"id"`
+	ContractorID    *uuid.UUID `json:"contractor_id"`
+	FullName        string     `json:"full_name"`
+	IIN             string     `json:"iin"`
+	BirthYear       int        `json:"birth_year"`
+	Phone           string     `json:"phone"`
+	IsActive        bool       `json:"is_active"`
+	ClearedForWork  bool       `json:"cleared_for_work"`
+	ClearanceIssues []string   `json:"clearance_issues"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// VehicleDTO — представление транспортного средства в ответах API вместе с допуском к работе.
+type VehicleDTO struct {
+	ID              uuid.UUID  `json:"id"`
+	ContractorID    *uuid.UUID `json:"contractor_id"`
+	PlateNumber     string     `json:"plate_number"`
+	PlateDisplay    string     `json:"plate_display"`
+	Type            string     `json:"type"`
+	HaulsSnow       bool       `json:"hauls_snow"`
+	Brand           string     `json:"brand"`
+	Model           string     `json:"model"`
+	Color           string     `json:"color"`
+	Year            int        `json:"year"`
+	BodyVolumeM3    float64    `json:"body_volume_m3"`
+	BucketVolumeM3  float64    `json:"bucket_volume_m3"`
+	PayloadTonnes   float64    `json:"payload_tonnes"`
+	DriverID        *uuid.UUID `json:"driver_id"`
+	IsActive        bool       `json:"is_active"`
+	ClearedForWork  bool       `json:"cleared_for_work"`
+	ClearanceIssues []string   `json:"clearance_issues"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func toOrganizationDTO(org models.Organization) OrganizationDTO {
+	return OrganizationDTO{
+		ID:           org.ID,
+		Name:         org.Name,
+		Type:         org.Type,
+		BIN:          org.BIN,
+		HeadFullName: org.HeadFullName,
+		Address:      org.Address,
+		Phone:        org.Phone,
+		ParentOrgID:  org.ParentOrgID,
+		IsActive:     org.IsActive,
+		CreatedAt:    org.CreatedAt,
+		UpdatedAt:    org.UpdatedAt,
+	}
+}
+
+func toOrganizationDTOs(orgs []models.Organization) []OrganizationDTO {
+	result := make([]OrganizationDTO, 0, len(orgs))
+	for _, org := range orgs {
+		result = append(result, toOrganizationDTO(org))
+	}
+	return result
+}
+
+func toUserDTO(user models.User) UserDTO {
+	return UserDTO{
+		ID:             user.ID,
+		Phone:          user.Phone,
+		Role:           user.Role,
+		Login:          user.Login,
+		OrganizationID: user.OrganizationID,
+		DriverID:       user.DriverID,
+		IsActive:       user.IsActive,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+	}
+}
+
+func toDriverDTO(driver models.Driver, clearance models.Clearance) DriverDTO {
+	return DriverDTO{
+		ID:              driver.ID,
+		ContractorID:    driver.ContractorID,
+		FullName:        driver.FullName,
+		IIN:             driver.IIN,
+		BirthYear:       driver.BirthYear,
+		Phone:           driver.Phone,
+		IsActive:        driver.IsActive,
+		ClearedForWork:  clearance.Cleared,
+		ClearanceIssues: clearanceIssues(clearance),
+		CreatedAt:       driver.CreatedAt,
+		UpdatedAt:       driver.UpdatedAt,
+	}
+}
+
+func toVehicleDTO(vehicle models.Vehicle, clearance models.Clearance) VehicleDTO {
+	spec, _ := models.LookupVehicleType(vehicle.Type)
+
+	return VehicleDTO{
+		ID:              vehicle.ID,
+		ContractorID:    vehicle.ContractorID,
+		PlateNumber:     vehicle.PlateNumber,
+		PlateDisplay:    vehicle.PlateDisplay,
+		Type:            vehicle.Type,
+		HaulsSnow:       spec.HaulsSnow,
+		Brand:           vehicle.Brand,
+		Model:           vehicle.Model,
+		Color:           vehicle.Color,
+		Year:            vehicle.Year,
+		BodyVolumeM3:    vehicle.BodyVolumeM3,
+		BucketVolumeM3:  vehicle.BucketVolumeM3,
+		PayloadTonnes:   vehicle.PayloadTonnes,
+		DriverID:        vehicle.DriverID,
+		IsActive:        vehicle.IsActive,
+		ClearedForWork:  clearance.Cleared,
+		ClearanceIssues: clearanceIssues(clearance),
+		CreatedAt:       vehicle.CreatedAt,
+		UpdatedAt:       vehicle.UpdatedAt,
+	}
+}
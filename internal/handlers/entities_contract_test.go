@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// TestEntityResponsesMatchSpec проверяет по спецификации успешные ответы с
+// сущностями; база заменена заглушкой с ожидаемыми запросами.
+func TestEntityResponsesMatchSpec(t *testing.T) {
+	env := newContractEnv(t)
+	now := time.Now()
+
+	t.Run("get organization", func(t *testing.T) {
+		mock := stubDB(t)
+		orgID := uuid.New()
+		mock.ExpectQuery(`SELECT * FROM organizations WHERE id = $1 AND is_active = $2`).
+			WillReturnRows(rows("id", orgID, "name", "ТОО Снег", "type", models.OrgTypeToo, "bin", "123456789012",
+				"parent_org_id", uuid.New(), "is_active", true, "created_at", now, "updated_at", now))
+
+		w := env.do(t, http.MethodGet, "/organizations/"+orgID.String(), identity(models.RoleAkimatAdmin), "")
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200; body: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("create organization", func(t *testing.T) {
+		mock := stubDB(t)
+		mock.ExpectQuery(`SELECT * FROM organizations WHERE bin = $1`).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT * FROM users WHERE phone = $1`).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO organizations`).WillReturnRows(rows("id", uuid.New()))
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnRows(rows("id", uuid.New()))
+		mock.ExpectQuery(`INSERT INTO outbox_events`).WillReturnRows(rows("sequence", int64(1)))
+		mock.ExpectCommit()
+
+		w := env.do(t, http.MethodPost, "/organizations", identity(models.RoleAkimatAdmin),
+			`{"type":"TOO","name":"ТОО Снег","bin":"123456789012","admin_phone":"+77010000001"}`)
+		if w.Code != http.StatusCreated {
+			t.Errorf("status = %d, want 201; body: %s", w.Code, w.Body)
+		}
+	})
+	t.Run("get driver", func(t *testing.T) {
+		mock := stubDB(t)
+		tooID := uuid.New()
+		driverID := uuid.New()
+		mock.ExpectQuery(`SELECT * FROM drivers WHERE id = $1 AND is_active = $2`).
+			WillReturnRows(rows("id", driverID, "contractor_id", uuid.New(), "full_name", "Иванов Иван", "iin", "900101300123",
+				"birth_year", 1990, "phone", "+77010000002", "is_active", true, "created_at", now, "updated_at", now))
+		mock.ExpectQuery(`SELECT count(*) FROM organizations WHERE id = $1 AND parent_org_id = $2`).
+			WithArgs(sqlmock.AnyArg(), tooID).
+			WillReturnRows(rows("count", 1))
+		mock.ExpectQuery(`SELECT * FROM driver_documents WHERE driver_id = $1 AND is_active = $2`).
+			WillReturnRows(rows("id", uuid.New(), "driver_id", driverID, "type", models.DriverDocLicense,
+				"number", "AB123456", "category", "C", "issued_at", now.AddDate(-1, 0, 0), "expires_at", now.AddDate(1, 0, 0),
+				"is_active", true, "created_at", now, "updated_at", now))
+
+		header := identity(models.RoleTooAdmin)
+		header.Set("X-Org-ID", tooID.String())
+		w := env.do(t, http.MethodGet, "/drivers/"+driverID.String(), header, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200; body: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("create driver", func(t *testing.T) {
+		mock := stubDB(t)
+		expectContractInForce(mock, now)
+		mock.ExpectQuery(`SELECT * FROM drivers WHERE iin = $1`).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT * FROM drivers WHERE phone = $1 AND is_active = $2`).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectQuery(`SELECT * FROM users WHERE phone = $1 AND is_active = $2`).WillReturnRows(sqlmock.NewRows(nil))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO drivers`).WillReturnRows(rows("id", uuid.New()))
+		mock.ExpectQuery(`INSERT INTO users`).WillReturnRows(rows("id", uuid.New()))
+		mock.ExpectQuery(`INSERT INTO outbox_events`).WillReturnRows(rows("sequence", int64(1)))
+		mock.ExpectCommit()
+
+		w := env.do(t, http.MethodPost, "/drivers", identity(models.RoleContractorAdmin),
+			`{"full_name":"Иванов Иван","iin":"900101300123","birth_year":1990,"phone":"+77010000002"}`)
+		if w.Code != http.StatusCreated {
+			t.Errorf("status = %d, want 201; body: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("get vehicle", func(t *testing.T) {
+		mock := stubDB(t)
+		contractorID := uuid.New()
+		vehicleID := uuid.New()
+		mock.ExpectQuery(`SELECT * FROM vehicles WHERE id = $1`).
+			WillReturnRows(rows("id", vehicleID, "contractor_id", contractorID, "plate_number", "123ABC02", "plate_display", "123 ABC 02",
+				"type", models.VehicleTypeDumpTruck, "brand", "КАМАЗ", "model", "65115", "year", 2020, "body_volume_m3", 15.0,
+				"is_active", true, "created_at", now, "updated_at", now))
+		expectVehicleDocuments(mock)
+
+		header := identity(models.RoleContractorAdmin)
+		header.Set("X-Org-ID", contractorID.String())
+		w := env.do(t, http.MethodGet, "/vehicles/"+vehicleID.String(), header, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200; body: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("create vehicle", func(t *testing.T) {
+		mock := stubDB(t)
+		expectContractInForce(mock, now)
+		expectContractInForce(mock, now)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO vehicles`).WillReturnRows(rows("id", uuid.New()))
+		mock.ExpectQuery(`INSERT INTO outbox_events`).WillReturnRows(rows("sequence", int64(1)))
+		mock.ExpectCommit()
+		expectVehicleDocuments(mock)
+
+		w := env.do(t, http.MethodPost, "/vehicles", identity(models.RoleContractorAdmin),
+			`{"plate_number":"123 ABC 02","type":"DUMP_TRUCK","brand":"КАМАЗ","model":"65115","year":2020,"body_volume_m3":15}`)
+		if w.Code != http.StatusCreated {
+			t.Errorf("status = %d, want 201; body: %s", w.Code, w.Body)
+		}
+	})
+}
+
+// expectContractInForce ожидает проверку действующего договора подрядчика.
+func expectContractInForce(mock sqlmock.Sqlmock, now time.Time) {
+	mock.ExpectQuery(`SELECT * FROM contracts WHERE contractor_org_id = $1 AND status = $2`).
+		WillReturnRows(rows("id", uuid.New(), "number", "Д-1", "status", models.ContractStatusActive,
+			"start_date", now.AddDate(0, -1, 0), "end_date", now.AddDate(0, 1, 0)))
+}
+
+// expectVehicleDocuments ожидает загрузку документов техники для расчёта допуска.
+func expectVehicleDocuments(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT * FROM vehicle_documents WHERE vehicle_id IN ($1) AND is_active = $2`).
+		WillReturnRows(sqlmock.NewRows(nil))
+}
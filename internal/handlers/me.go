@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// UpdateMeRequest содержит поля профиля, которые пользователь меняет сам.
+// Новый телефон вступает в силу только после подтверждения кодом.
+type UpdateMeRequest struct {
+	Phone *string `json:"phone" binding:"omitempty,min=5,max=32"`
+}
+
+// VerifyPhoneRequest подтверждает новый номер телефона кодом из SMS.
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// PhoneVerificationDTO сообщает о созданном запросе на смену номера.
+type PhoneVerificationDTO struct {
+	Phone     string    `json:"phone"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SendVerificationCode доставляет код подтверждения на новый номер. Функцию задают
+// при старте сервиса; пока SMS-шлюз не подключён, смена телефона недоступна.
+var SendVerificationCode func(phone, code string) error
+
+// PrintVerificationCode выводит код в stderr вместо SMS, минуя журнал. Подключается
+// только при APP_ENV=development.
+func PrintVerificationCode(phone, code string) error {
+	_, err := fmt.Fprintf(os.Stderr, "код подтверждения для %s: %s\n", phone, code)
+	return err
+}
+
+// loadCurrentUser загружает активного пользователя текущего запроса.
+func loadCurrentUser(c *gin.Context) (*models.User, bool) {
+	principal, ok := auth.Require(c)
+	if !ok {
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", principal.UserID, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeUserNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch user", err))
+		}
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// currentDriverID определяет водителя, связанного с текущим пользователем.
+func currentDriverID(c *gin.Context) (uuid.UUID, bool) {
+	principal, ok := auth.Require(c)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if principal.DriverID == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound).WithMessage("driver profile is not linked to the current user"))
+		return uuid.Nil, false
+	}
+
+	return *principal.DriverID, true
+}
+
+// GetMe возвращает профиль текущего пользователя и его организацию. Водитель
+// дополнительно получает карточку водителя, закреплённую технику и документы.
+func GetMe(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{
+		"user":         toUserDTO(*user),
+		"organization": nil,
+	}
+
+	orgID := user.OrganizationID
+	if user.Role == models.RoleDriver && user.DriverID != nil {
+		var driver models.Driver
+		if err := database.WithContext(c.Request.Context()).Where("id = ?", *user.DriverID).First(&driver).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
+			} else {
+				apierror.Respond(c, apierror.Internal("failed to fetch driver", err))
+			}
+			return
+		}
+		if driver.ContractorID != nil {
+			orgID = driver.ContractorID
+		}
+
+		var docs []models.DriverDocument
+		if err := database.WithContext(c.Request.Context()).Where("driver_id = ? AND is_active = ?", driver.ID, true).Order("expires_at").Find(&docs).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to fetch driver documents", err))
+			return
+		}
+
+		now := time.Now()
+		documents := make([]DriverDocumentDTO, 0, len(docs))
+		for _, doc := range docs {
+			documents = append(documents, toDriverDocumentDTO(doc, now))
+		}
+
+		response["driver"] = toDriverDTO(driver, models.EvaluateDriverClearance(driver, docs, now))
+		response["documents"] = documents
+		response["vehicle"] = nil
+
+		var vehicle models.Vehicle
+		err := database.WithContext(c.Request.Context()).Where("driver_id = ? AND is_active = ?", driver.ID, true).First(&vehicle).Error
+		switch {
+		case err == nil:
+			clearances, err := vehicleClearances(database.WithContext(c.Request.Context()), []models.Vehicle{vehicle})
+			if err != nil {
+				apierror.Respond(c, apierror.Internal("failed to evaluate vehicle clearance", err))
+				return
+			}
+			response["vehicle"] = toVehicleDTO(vehicle, clearances[vehicle.ID])
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			apierror.Respond(c, apierror.Internal("failed to fetch vehicle", err))
+			return
+		}
+	}
+
+	if orgID != nil {
+		var org models.Organization
+		err := database.WithContext(c.Request.Context()).Where("id = ?", *orgID).First(&org).Error
+		switch {
+		case err == nil:
+			response["organization"] = toOrganizationDTO(org)
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
+			return
+		}
+	}
+
+	var pending models.PhoneVerification
+	err := database.WithContext(c.Request.Context()).Where("user_id = ? AND confirmed_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Order("created_at DESC").First(&pending).Error
+	switch {
+	case err == nil:
+		response["pending_phone"] = PhoneVerificationDTO{Phone: pending.Phone, ExpiresAt: pending.ExpiresAt}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Respond(c, apierror.Internal("failed to fetch phone verification", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// generateVerificationCode возвращает случайный шестизначный код.
+func generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// UpdateMe принимает изменения профиля. Смена телефона создаёт запрос на подтверждение
+// и отправляет код на новый номер; прежний номер действует до подтверждения.
+func UpdateMe(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if req.Phone == nil {
+		c.JSON(http.StatusOK, gin.H{"user": toUserDTO(*user)})
+		return
+	}
+
+	if *req.Phone == user.Phone {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).WithMessage("phone number is unchanged"))
+		return
+	}
+
+	if SendVerificationCode == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeNotImplemented).WithMessage("SMS delivery is not configured"))
+		return
+	}
+
+	conflict, err := findPhoneConflictForUser(database.WithContext(c.Request.Context()), *user, *req.Phone)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check phone uniqueness", err))
+		return
+	}
+	if conflict != nil {
+		respondConflict(c, conflict)
+		return
+	}
+
+	code, err := generateVerificationCode()
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to generate verification code", err))
+		return
+	}
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to hash verification code", err))
+		return
+	}
+
+	verification := models.PhoneVerification{
+		UserID:    user.ID,
+		Phone:     *req.Phone,
+		CodeHash:  string(codeHash),
+		ExpiresAt: time.Now().Add(models.PhoneVerificationTTL),
+	}
+
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// Новый запрос заменяет прежние неподтверждённые.
+		if err := tx.Where("user_id = ? AND confirmed_at IS NULL", user.ID).Delete(&models.PhoneVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&verification).Error
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create phone verification", err))
+		return
+	}
+
+	if err := SendVerificationCode(verification.Phone, code); err != nil {
+		apierror.Respond(c, apierror.Internal("failed to send verification code", err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"user":          toUserDTO(*user),
+		"pending_phone": PhoneVerificationDTO{Phone: verification.Phone, ExpiresAt: verification.ExpiresAt},
+	})
+}
+
+// findPhoneConflictForUser проверяет, не занят ли номер другим пользователем или водителем.
+func findPhoneConflictForUser(db *gorm.DB, user models.User, phone string) (*UniquenessConflict, error) {
+	if user.DriverID != nil {
+		return findDriverConflict(db, "", phone, user.DriverID, false)
+	}
+	return findUserPhoneConflict(db, phone, &user.ID)
+}
+
+// VerifyMyPhone проверяет код и применяет новый номер к пользователю и,
+// для водителя, к карточке водителя.
+func VerifyMyPhone(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	var verification models.PhoneVerification
+	if err := database.WithContext(c.Request.Context()).Where("user_id = ? AND confirmed_at IS NULL", user.ID).
+		Order("created_at DESC").First(&verification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeVerificationNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch phone verification", err))
+		}
+		return
+	}
+
+	now := time.Now()
+	if !now.Before(verification.ExpiresAt) {
+		apierror.Respond(c, apierror.New(apierror.CodeVerificationFailed))
+		return
+	}
+	if verification.Attempts >= models.PhoneVerificationMaxAttempts {
+		apierror.Respond(c, apierror.New(apierror.CodeVerificationFailed).WithMessage("too many verification attempts, request a new code"))
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(verification.CodeHash), []byte(req.Code)) != nil {
+		if err := database.WithContext(c.Request.Context()).Model(&verification).Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to update phone verification", err))
+			return
+		}
+		apierror.Respond(c, apierror.New(apierror.CodeVerificationFailed))
+		return
+	}
+
+	conflict, err := findPhoneConflictForUser(database.WithContext(c.Request.Context()), *user, verification.Phone)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check phone uniqueness", err))
+		return
+	}
+	if conflict != nil {
+		respondConflict(c, conflict)
+		return
+	}
+
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&verification).Update("confirmed_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("phone", verification.Phone).Error; err != nil {
+			return err
+		}
+		if user.DriverID != nil {
+			return tx.Model(&models.Driver{}).Where("id = ?", *user.DriverID).Update("phone", verification.Phone).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity))
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to apply phone change", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": toUserDTO(*user)})
+}
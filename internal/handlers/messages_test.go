@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+)
+
+// TestMessagesTranslated проверяет, что у каждого сообщения, передаваемого в
+// WithMessage, есть перевод на русский и казахский языки.
+func TestMessagesTranslated(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || selector.Sel.Name != "WithMessage" {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			message, err := strconv.Unquote(literal.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, lang := range []i18n.Lang{i18n.LangRU, i18n.LangKK} {
+				if !i18n.Translated(lang, message) {
+					t.Errorf("%s: no %s translation for %q", fset.Position(literal.Pos()), lang, message)
+				}
+			}
+			return true
+		})
+	}
+}
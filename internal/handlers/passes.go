@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/pass"
+)
+
+// Срок действия пропуска: по умолчанию — одна смена, не дольше суток.
+const (
+	defaultPassTTL = 12 * time.Hour
+	maxPassTTL     = models.MaxShiftDuration
+	passQRSize     = 512
+)
+
+// Причины, по которым проверка пропуска не пройдена.
+const (
+	PassReasonMalformed          = "PASS_MALFORMED"
+	PassReasonSignatureInvalid   = "PASS_SIGNATURE_INVALID"
+	PassReasonExpired            = "PASS_EXPIRED"
+	PassReasonDriverInactive     = "DRIVER_INACTIVE"
+	PassReasonVehicleInactive    = "VEHICLE_INACTIVE"
+	PassReasonDriverReassigned   = "DRIVER_REASSIGNED"
+	PassReasonVehicleReassigned  = "VEHICLE_REASSIGNED"
+	PassReasonContractorInactive = "CONTRACTOR_INACTIVE"
+	PassReasonTooInactive        = "TOO_INACTIVE"
+	PassReasonContractNotInForce = "CONTRACT_NOT_IN_FORCE"
+)
+
+// IssuePassRequest запрашивает пропуск на полигон для пары водитель + техника.
+type IssuePassRequest struct {
+	DriverID   uuid.UUID `json:"driver_id" binding:"required"`
+	VehicleID  uuid.UUID `json:"vehicle_id" binding:"required"`
+	TTLMinutes int       `json:"ttl_minutes" binding:"omitempty,min=5,max=1440"`
+}
+
+// VerifyPassRequest содержит считанный с QR-кода пропуск.
+type VerifyPassRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PassDTO — выданный пропуск и, по запросу, его QR-код в PNG.
+type PassDTO struct {
+	ID           uuid.UUID `json:"id"`
+	Token        string    `json:"token"`
+	DriverID     uuid.UUID `json:"driver_id"`
+	VehicleID    uuid.UUID `json:"vehicle_id"`
+	ContractorID uuid.UUID `json:"contractor_id"`
+	DriverName   string    `json:"driver_full_name"`
+	Plate        string    `json:"vehicle_plate_display"`
+	IssuedAt     time.Time `json:"issued_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	KeyID        string    `json:"key_id"`
+	QRPNG        string    `json:"qr_png,omitempty"`
+}
+
+// PassVerificationDTO — результат онлайн-проверки пропуска на въезде.
+type PassVerificationDTO struct {
+	Valid   bool     `json:"valid"`
+	Reasons []string `json:"reasons"`
+	Pass    *PassDTO `json:"pass"`
+}
+
+func toPassDTO(token string, claims pass.Claims) PassDTO {
+	return PassDTO{
+		ID:           claims.PassID,
+		Token:        token,
+		DriverID:     claims.DriverID,
+		VehicleID:    claims.VehicleID,
+		ContractorID: claims.ContractorID,
+		DriverName:   claims.DriverName,
+		Plate:        claims.Plate,
+		IssuedAt:     time.Unix(claims.IssuedAt, 0).UTC(),
+		ExpiresAt:    time.Unix(claims.ExpiresAt, 0).UTC(),
+		KeyID:        claims.KeyID,
+	}
+}
+
+// RegisterPublicRoutes регистрирует маршруты без авторизации: охрана полигона
+// проверяет пропуска без учётной записи в системе.
+func RegisterPublicRoutes(public *gin.RouterGroup) {
+	public.GET("/passes/public-key", GetPassPublicKey)
+	public.POST("/passes/verify", VerifyPass)
+}
+
+// loadPassResources загружает водителя и технику и проверяет, что на них можно
+// выдать пропуск: оба активны, относятся к одному подрядчику и допущены к работе.
+func loadPassResources(db *gorm.DB, driverID, vehicleID uuid.UUID) (*models.Driver, *models.Vehicle, *apierror.Error) {
+	var driver models.Driver
+	if err := db.Where("id = ?", driverID).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.New(apierror.CodeDriverNotFound)
+		}
+		return nil, nil, apierror.Internal("failed to fetch driver", err)
+	}
+
+	var vehicle models.Vehicle
+	if err := db.Where("id = ?", vehicleID).First(&vehicle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.New(apierror.CodeVehicleNotFound)
+		}
+		return nil, nil, apierror.Internal("failed to fetch vehicle", err)
+	}
+
+	if !driver.IsActive || driver.ContractorID == nil {
+		return nil, nil, apierror.New(apierror.CodePassNotIssuable).
+			WithMessage("inactive driver cannot receive a pass").With("driver_id", driver.ID)
+	}
+	if !vehicle.IsActive {
+		return nil, nil, apierror.New(apierror.CodePassNotIssuable).
+			WithMessage("inactive vehicle cannot receive a pass").With("vehicle_id", vehicle.ID)
+	}
+	if vehicle.ContractorID == nil || *vehicle.ContractorID != *driver.ContractorID {
+		return nil, nil, apierror.New(apierror.CodePassNotIssuable).
+			WithMessage("vehicle does not belong to the driver's contractor")
+	}
+
+	driverClear, err := driverClearanceFor(db, driver, vehicle.Type)
+	if err != nil {
+		return nil, nil, apierror.Internal("failed to evaluate driver clearance", err)
+	}
+	if !driverClear.Cleared {
+		return nil, nil, apierror.New(apierror.CodePassNotIssuable).
+			WithMessage("driver is not cleared for work").With("clearance_issues", clearanceIssues(driverClear))
+	}
+
+	clearances, err := vehicleClearances(db, []models.Vehicle{vehicle})
+	if err != nil {
+		return nil, nil, apierror.Internal("failed to evaluate vehicle clearance", err)
+	}
+	if vehicleClear := clearances[vehicle.ID]; !vehicleClear.Cleared {
+		return nil, nil, apierror.New(apierror.CodePassNotIssuable).
+			WithMessage("vehicle is not cleared for work").With("clearance_issues", clearanceIssues(vehicleClear))
+	}
+
+	return &driver, &vehicle, nil
+}
+
+// canIssueDriverPass проверяет, что водитель запрашивает пропуск на себя и на
+// закреплённую за ним технику или технику его текущей смены.
+func canIssueDriverPass(c *gin.Context, driver models.Driver, vehicle models.Vehicle) bool {
+	selfID, ok := currentDriverID(c)
+	if !ok {
+		return false
+	}
+	if selfID != driver.ID {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return false
+	}
+	if vehicle.DriverID != nil && *vehicle.DriverID == driver.ID {
+		return true
+	}
+
+	now := time.Now()
+	var count int64
+	if err := database.WithContext(c.Request.Context()).Model(&models.Shift{}).
+		Where("driver_id = ? AND vehicle_id = ? AND status = ? AND planned_start <= ? AND planned_end > ?",
+			driver.ID, vehicle.ID, models.ShiftStatusPlanned, now, now).
+		Count(&count).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch shifts", err))
+		return false
+	}
+	if count == 0 {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope).
+			WithMessage("vehicle is not assigned to the driver"))
+		return false
+	}
+
+	return true
+}
+
+// IssuePass выдаёт подписанный пропуск на полигон. Подрядчик и ТОО выдают пропуска
+// водителям своих подрядчиков, водитель — себе на закреплённую технику.
+// Параметр qr=true добавляет в ответ QR-код в PNG (base64).
+func IssuePass(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if role != models.RoleContractorAdmin && role != models.RoleTooAdmin && role != models.RoleDriver {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	var req IssuePassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+	if pass.Default == nil {
+		apierror.Respond(c, apierror.Internal("pass signing key is not initialized", nil))
+		return
+	}
+
+	driver, vehicle, apiErr := loadPassResources(database.WithContext(c.Request.Context()), req.DriverID, req.VehicleID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if role == models.RoleDriver {
+		if !canIssueDriverPass(c, *driver, *vehicle) {
+			return
+		}
+	} else {
+		allowed, err := canAccessContractor(database.WithContext(c.Request.Context()), role, currentOrgUUID, driver.ContractorID)
+		if err != nil {
+			apierror.Respond(c, apierror.Internal("failed to check contractor scope", err))
+			return
+		}
+		if !allowed {
+			apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+			return
+		}
+	}
+
+	if _, apiErr := requireContractInForce(database.WithContext(c.Request.Context()), *driver.ContractorID); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	ttl := defaultPassTTL
+	if req.TTLMinutes > 0 {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+	if ttl > maxPassTTL {
+		ttl = maxPassTTL
+	}
+
+	now := time.Now()
+	claims := pass.Claims{
+		PassID:       uuid.New(),
+		DriverID:     driver.ID,
+		VehicleID:    vehicle.ID,
+		ContractorID: *driver.ContractorID,
+		DriverName:   driver.FullName,
+		Plate:        vehicle.PlateDisplay,
+		IssuedAt:     now.Unix(),
+		ExpiresAt:    now.Add(ttl).Unix(),
+	}
+
+	token, err := pass.Default.Sign(claims)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to sign pass", err))
+		return
+	}
+	claims.KeyID = pass.Default.KeyID()
+
+	dto := toPassDTO(token, claims)
+	if c.Query("qr") == "true" {
+		png, err := qrcode.Encode(token, qrcode.Medium, passQRSize)
+		if err != nil {
+			apierror.Respond(c, apierror.Internal("failed to render pass QR code", err))
+			return
+		}
+		dto.QRPNG = base64.StdEncoding.EncodeToString(png)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"pass": dto})
+}
+
+// GetPassPublicKey отдаёт открытый ключ для офлайн-проверки подписи пропусков.
+func GetPassPublicKey(c *gin.Context) {
+	if pass.Default == nil {
+		apierror.Respond(c, apierror.Internal("pass signing key is not initialized", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":    "Ed25519",
+		"key_id":       pass.Default.KeyID(),
+		"public_key":   base64.StdEncoding.EncodeToString(pass.Default.PublicKey()),
+		"token_format": "SP1.<base64url(payload JSON)>.<base64url(Ed25519 signature of \"SP1.<payload>\")>",
+	})
+}
+
+// passStatusReasons сверяет пропуск с текущим состоянием водителя, техники,
+// подрядчика, его ТОО и договора.
+func passStatusReasons(db *gorm.DB, claims pass.Claims) ([]string, error) {
+	var reasons []string
+
+	var driver models.Driver
+	err := db.Where("id = ?", claims.DriverID).First(&driver).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reasons = append(reasons, PassReasonDriverInactive)
+	case err != nil:
+		return nil, err
+	case !driver.IsActive:
+		reasons = append(reasons, PassReasonDriverInactive)
+	case driver.ContractorID == nil || *driver.ContractorID != claims.ContractorID:
+		reasons = append(reasons, PassReasonDriverReassigned)
+	}
+
+	var vehicle models.Vehicle
+	err = db.Where("id = ?", claims.VehicleID).First(&vehicle).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reasons = append(reasons, PassReasonVehicleInactive)
+	case err != nil:
+		return nil, err
+	case !vehicle.IsActive:
+		reasons = append(reasons, PassReasonVehicleInactive)
+	case vehicle.ContractorID == nil || *vehicle.ContractorID != claims.ContractorID:
+		reasons = append(reasons, PassReasonVehicleReassigned)
+	}
+
+	var contractor models.Organization
+	err = db.Where("id = ?", claims.ContractorID).First(&contractor).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reasons = append(reasons, PassReasonContractorInactive)
+	case err != nil:
+		return nil, err
+	case !contractor.IsActive:
+		reasons = append(reasons, PassReasonContractorInactive)
+	}
+
+	if contractor.ParentOrgID != nil {
+		var too models.Organization
+		err = db.Where("id = ?", *contractor.ParentOrgID).First(&too).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			reasons = append(reasons, PassReasonTooInactive)
+		case err != nil:
+			return nil, err
+		case !too.IsActive:
+			reasons = append(reasons, PassReasonTooInactive)
+		}
+	}
+
+	contract, err := findContractInForce(db, claims.ContractorID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil {
+		reasons = append(reasons, PassReasonContractNotInForce)
+	}
+
+	return reasons, nil
+}
+
+// VerifyPass проверяет подпись и срок пропуска, а затем — что водитель, техника,
+// подрядчик и его ТОО по-прежнему активны. Недействительный пропуск — это
+// результат проверки, а не ошибка запроса: ответ всегда 200 с valid и reasons.
+func VerifyPass(c *gin.Context) {
+	var req VerifyPassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if pass.Default == nil {
+		apierror.Respond(c, apierror.Internal("pass signing key is not initialized", nil))
+		return
+	}
+
+	claims, err := pass.Default.Verify(req.Token, time.Now())
+	switch {
+	case errors.Is(err, pass.ErrMalformed):
+		c.JSON(http.StatusOK, PassVerificationDTO{Reasons: []string{PassReasonMalformed}})
+		return
+	case errors.Is(err, pass.ErrSignature):
+		c.JSON(http.StatusOK, PassVerificationDTO{Reasons: []string{PassReasonSignatureInvalid}})
+		return
+	case errors.Is(err, pass.ErrExpired):
+		dto := toPassDTO(req.Token, claims)
+		c.JSON(http.StatusOK, PassVerificationDTO{Reasons: []string{PassReasonExpired}, Pass: &dto})
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	reasons, err := passStatusReasons(database.WithContext(c.Request.Context()), claims)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to verify pass", err))
+		return
+	}
+	if reasons == nil {
+		reasons = []string{}
+	}
+
+	dto := toPassDTO(req.Token, claims)
+	c.JSON(http.StatusOK, PassVerificationDTO{
+		Valid:   len(reasons) == 0,
+		Reasons: reasons,
+		Pass:    &dto,
+	})
+}
@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
 	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
 	"github.com/MSTimX/Snowops-roles/internal/models"
 )
 
@@ -34,11 +39,25 @@ type CreateDriverRequest struct {
 
 // RegisterRoutes регистрирует HTTP-маршруты для API.
 func RegisterRoutes(api *gin.RouterGroup) {
-	api.GET("/organizations", ListOrganizations)
-	api.POST("/organizations", CreateOrganization)
-	api.GET("/organizations/:id", GetOrganization)
-	api.PUT("/organizations/:id", UpdateOrganization)
-	api.DELETE("/organizations/:id", DeleteOrganization)
+	// Служебные учётные записи допускаются только к разделам из выданных разрешений.
+	api.Use(RequireRouteScope(api.BasePath()))
+
+	// Без действующего договора подрядчику закрыты организации и приём водителей и
+	// техники; остальные маршруты, включая договоры и профиль, доступны.
+	contracted := RequireActiveContract()
+
+	api.GET("/contracts", ListContracts)
+	api.POST("/contracts", CreateContract)
+	api.GET("/contracts/expiring", ListExpiringContracts)
+	api.GET("/contracts/:id", GetContract)
+	api.PUT("/contracts/:id", UpdateContract)
+	api.DELETE("/contracts/:id", DeleteContract)
+
+	api.GET("/organizations", contracted, ListOrganizations)
+	api.POST("/organizations", contracted, CreateOrganization)
+	api.GET("/organizations/:id", contracted, GetOrganization)
+	api.PUT("/organizations/:id", contracted, UpdateOrganization)
+	api.DELETE("/organizations/:id", contracted, DeleteOrganization)
 
 	api.GET("/users", FindUser)
 	api.GET("/users/:id", GetUser)
@@ -46,29 +65,87 @@ func RegisterRoutes(api *gin.RouterGroup) {
 
 	drivers := api.Group("/drivers")
 	drivers.GET("", ListDrivers)
-	drivers.POST("", CreateDriver)
+	drivers.POST("", contracted, CreateDriver)
 	drivers.GET("/:id", GetDriver)
 	drivers.PUT("/:id", UpdateDriver)
 	drivers.DELETE("/:id", DeleteDriver)
+	drivers.POST("/:id/rehire", contracted, RehireDriver)
+
+	drivers.GET("/:id/documents", ListDriverDocuments)
+	drivers.POST("/:id/documents", CreateDriverDocument)
+	drivers.GET("/:id/documents/:docId", GetDriverDocument)
+	drivers.PUT("/:id/documents/:docId", UpdateDriverDocument)
+	drivers.DELETE("/:id/documents/:docId", DeleteDriverDocument)
+
+	api.GET("/driver-documents/expiring", ListExpiringDriverDocuments)
+
+	vehicles := api.Group("/vehicles")
+	vehicles.GET("", ListVehicles)
+	vehicles.POST("", contracted, CreateVehicle)
+	vehicles.GET("/:id", GetVehicle)
+	vehicles.PUT("/:id", UpdateVehicle)
+	vehicles.DELETE("/:id", DeleteVehicle)
+
+	vehicles.GET("/:id/documents", ListVehicleDocuments)
+	vehicles.POST("/:id/documents", CreateVehicleDocument)
+	vehicles.GET("/:id/documents/:docId", GetVehicleDocument)
+	vehicles.PUT("/:id/documents/:docId", UpdateVehicleDocument)
+	vehicles.DELETE("/:id/documents/:docId", DeleteVehicleDocument)
+
+	api.GET("/vehicle-documents/expiring", ListExpiringVehicleDocuments)
+
+	api.GET("/service-areas", ListServiceAreas)
+	api.POST("/service-areas", CreateServiceArea)
+	api.GET("/service-areas/coverage", GetAreaCoverage)
+	api.GET("/service-areas/:id", GetServiceArea)
+	api.PUT("/service-areas/:id", UpdateServiceArea)
+	api.DELETE("/service-areas/:id", DeleteServiceArea)
+
+	shifts := api.Group("/shifts")
+	shifts.GET("", ListShifts)
+	shifts.POST("", CreateShift)
+	shifts.GET("/on-duty", ListOnDutyShifts)
+	shifts.GET("/:id", GetShift)
+	shifts.PUT("/:id", UpdateShift)
+	shifts.DELETE("/:id", DeleteShift)
+
+	api.GET("/me", GetMe)
+	api.PATCH("/me", UpdateMe)
+	api.POST("/me/phone/verify", VerifyMyPhone)
+	api.GET("/me/shifts", ListMyShifts)
+
+	api.POST("/passes", IssuePass)
+
+	api.GET("/webhooks", ListWebhooks)
+	api.POST("/webhooks", CreateWebhook)
+	api.GET("/webhooks/:id", GetWebhook)
+	api.PUT("/webhooks/:id", UpdateWebhook)
+	api.DELETE("/webhooks/:id", DeleteWebhook)
+	api.POST("/webhooks/:id/rotate-secret", RotateWebhookSecret)
+	api.GET("/webhooks/:id/deliveries", ListWebhookDeliveries)
+	api.POST("/webhooks/:id/deliveries/:deliveryId/replay", ReplayWebhookDelivery)
+
+	api.GET("/service-accounts", ListServiceAccounts)
+	api.POST("/service-accounts", CreateServiceAccount)
+	api.GET("/service-accounts/:id", GetServiceAccount)
+	api.PUT("/service-accounts/:id", UpdateServiceAccount)
+	api.DELETE("/service-accounts/:id", DeleteServiceAccount)
+	api.POST("/service-accounts/:id/keys", CreateAPIKey)
+	api.POST("/service-accounts/:id/keys/:keyId/rotate", RotateAPIKey)
+	api.DELETE("/service-accounts/:id/keys/:keyId", RevokeAPIKey)
+
+	api.GET("/vehicle-types", ListVehicleTypes)
+	api.GET("/vehicle-capacity", ListVehicleCapacity)
 }
 
 func ListOrganizations(c *gin.Context) {
-	role := c.GetString("currentUserRole")
-	currentOrgID := c.GetString("currentOrgID")
-
-	if role == "" || currentOrgID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	currentOrgUUID, err := uuid.Parse(currentOrgID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid current organization id"})
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
@@ -76,17 +153,17 @@ func ListOrganizations(c *gin.Context) {
 
 	switch role {
 	case models.RoleAkimatAdmin:
-		if err := database.DB.Where("is_active = ?", true).Find(&orgs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organizations"})
+		if err := database.WithContext(c.Request.Context()).Where("is_active = ?", true).Find(&orgs).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to fetch organizations", err))
 			return
 		}
 	case models.RoleTooAdmin:
 		var currentOrg models.Organization
-		if err := database.DB.Where("id = ? AND is_active = ?", currentOrgUUID, true).First(&currentOrg).Error; err != nil {
+		if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", currentOrgUUID, true).First(&currentOrg).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+				apierror.Respond(c, apierror.New(apierror.CodeOrgNotFound))
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organization"})
+				apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
 			}
 			return
 		}
@@ -94,74 +171,81 @@ func ListOrganizations(c *gin.Context) {
 		orgs = append(orgs, currentOrg)
 
 		var contractors []models.Organization
-		if err := database.DB.Where("parent_org_id = ? AND type = ? AND is_active = ?", currentOrgUUID, models.OrgTypeContractor, true).Find(&contractors).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch contractor organizations"})
+		if err := database.WithContext(c.Request.Context()).Where("parent_org_id = ? AND type = ? AND is_active = ?", currentOrgUUID, models.OrgTypeContractor, true).Find(&contractors).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to fetch contractor organizations", err))
 			return
 		}
 
 		orgs = append(orgs, contractors...)
 	case models.RoleContractorAdmin:
 		var currentOrg models.Organization
-		if err := database.DB.Where("id = ? AND is_active = ?", currentOrgUUID, true).First(&currentOrg).Error; err != nil {
+		if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", currentOrgUUID, true).First(&currentOrg).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+				apierror.Respond(c, apierror.New(apierror.CodeOrgNotFound))
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organization"})
+				apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
 			}
 			return
 		}
 		orgs = append(orgs, currentOrg)
 	case models.RoleDriver:
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
+	c.JSON(http.StatusOK, gin.H{"organizations": toOrganizationDTOs(orgs)})
 }
 
 func CreateOrganization(c *gin.Context) {
-	currentUserID := c.GetString("currentUserID")
-	currentUserRole := c.GetString("currentUserRole")
-	currentOrgID := c.GetString("currentOrgID")
-
-	if currentUserID == "" || currentUserRole == "" || currentOrgID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	currentUserRole, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
 	var req CreateOrganizationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.FromBinding(err))
 		return
 	}
 
 	if !models.CanCreateOrganization(currentUserRole, req.Type) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
-	currentOrgUUID, err := uuid.Parse(currentOrgID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid current organization id"})
+	if req.AdminPhone == "" {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).WithDetails(apierror.FieldRequired("admin_phone")))
 		return
 	}
 
-	if req.AdminPhone == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "admin_phone is required"})
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
-	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+	conflict, err := findOrganizationBINConflict(database.WithContext(c.Request.Context()), req.BIN, nil)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check organization uniqueness", err))
+		return
+	}
+	if conflict == nil {
+		conflict, err = findUserPhoneConflict(database.WithContext(c.Request.Context()), req.AdminPhone, nil)
+		if err != nil {
+			apierror.Respond(c, apierror.Internal("failed to check admin uniqueness", err))
+			return
+		}
+	}
+	if conflict != nil {
+		respondConflict(c, conflict)
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := database.WithContext(c.Request.Context()).Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction"})
+		apierror.Respond(c, apierror.Internal("failed to start transaction", tx.Error))
 		return
 	}
 	defer func() {
@@ -185,7 +269,11 @@ func CreateOrganization(c *gin.Context) {
 
 	if err := tx.Create(&org).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).WithMessage("%s with this %s already exists", i18n.Label("organization"), i18n.Label("bin")))
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to create organization", err))
 		return
 	}
 
@@ -197,7 +285,7 @@ func CreateOrganization(c *gin.Context) {
 		adminRole = models.RoleContractorAdmin
 	default:
 		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported organization type"})
+		apierror.Respond(c, apierror.New(apierror.CodeUnsupportedOrgType))
 		return
 	}
 
@@ -212,7 +300,7 @@ func CreateOrganization(c *gin.Context) {
 		hashed, err := bcrypt.GenerateFromPassword([]byte(req.AdminPassword), bcrypt.DefaultCost)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			apierror.Respond(c, apierror.Internal("failed to hash password", err))
 			return
 		}
 		password := string(hashed)
@@ -221,75 +309,56 @@ func CreateOrganization(c *gin.Context) {
 
 	if err := tx.Create(&user).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create admin user"})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).WithMessage("%s with this %s already exists", i18n.Label("user"), i18n.Label("phone")))
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to create admin user", err))
+		return
+	}
+
+	if err := events.RecordOrganization(tx, events.OrganizationCreated, org); err != nil {
+		tx.Rollback()
+		apierror.Respond(c, apierror.Internal("failed to record organization event", err))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction"})
+		apierror.Respond(c, apierror.Internal("failed to commit transaction", err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"organization": gin.H{
-			"id":           org.ID,
-			"name":         org.Name,
-			"type":         org.Type,
-			"bin":          org.BIN,
-			"headFullName": org.HeadFullName,
-			"address":      org.Address,
-			"phone":        org.Phone,
-			"parentOrgID":  org.ParentOrgID,
-			"isActive":     org.IsActive,
-			"createdAt":    org.CreatedAt,
-			"updatedAt":    org.UpdatedAt,
-		},
-		"admin": gin.H{
-			"id":             user.ID,
-			"phone":          user.Phone,
-			"role":           user.Role,
-			"organizationID": user.OrganizationID,
-			"isActive":       user.IsActive,
-			"createdAt":      user.CreatedAt,
-			"updatedAt":      user.UpdatedAt,
-		},
+		"organization": toOrganizationDTO(org),
+		"admin":        toUserDTO(user),
 	})
 }
 
 func GetOrganization(c *gin.Context) {
-	role := c.GetString("currentUserRole")
-	currentOrgID := c.GetString("currentOrgID")
-
-	if role == "" || currentOrgID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	currentOrgUUID, err := uuid.Parse(currentOrgID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid current organization id"})
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
 	targetID := c.Param("id")
 	orgUUID, err := uuid.Parse(targetID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid organization id"))
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
 	var org models.Organization
-	if err := database.DB.Where("id = ? AND is_active = ?", orgUUID, true).First(&org).Error; err != nil {
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", orgUUID, true).First(&org).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeOrgNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organization"})
+			apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
 		}
 		return
 	}
@@ -299,63 +368,54 @@ func GetOrganization(c *gin.Context) {
 	case models.RoleTooAdmin:
 		if org.ID != currentOrgUUID {
 			if org.Type != models.OrgTypeContractor || org.ParentOrgID == nil || *org.ParentOrgID != currentOrgUUID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+				apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
 				return
 			}
 		}
 	case models.RoleContractorAdmin:
 		if org.ID != currentOrgUUID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
 			return
 		}
 	case models.RoleDriver:
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"organization": org})
+	c.JSON(http.StatusOK, gin.H{"organization": toOrganizationDTO(org)})
 }
 
 func UpdateOrganization(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "not implemented yet"})
+	apierror.Respond(c, apierror.New(apierror.CodeNotImplemented))
 }
 
 func DeleteOrganization(c *gin.Context) {
-	role := c.GetString("currentUserRole")
-	currentOrgID := c.GetString("currentOrgID")
-
-	if role == "" || currentOrgID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	currentOrgUUID, err := uuid.Parse(currentOrgID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid current organization id"})
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
 	targetID := c.Param("id")
 	orgUUID, err := uuid.Parse(targetID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid organization id"))
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
 	var org models.Organization
-	if err := database.DB.Where("id = ? AND is_active = ?", orgUUID, true).First(&org).Error; err != nil {
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", orgUUID, true).First(&org).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeOrgNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organization"})
+			apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
 		}
 		return
 	}
@@ -365,56 +425,78 @@ func DeleteOrganization(c *gin.Context) {
 	case models.RoleTooAdmin:
 		if org.ID != currentOrgUUID {
 			if org.Type != models.OrgTypeContractor || org.ParentOrgID == nil || *org.ParentOrgID != currentOrgUUID {
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+				apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
 				return
 			}
 		}
 	case models.RoleContractorAdmin:
 		if org.ID != currentOrgUUID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
 			return
 		}
 	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := database.WithContext(c.Request.Context()).Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction"})
+		apierror.Respond(c, apierror.Internal("failed to start transaction", tx.Error))
 		return
 	}
 
 	if err := tx.Model(&org).Update("is_active", false).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate organization"})
+		apierror.Respond(c, apierror.Internal("failed to deactivate organization", err))
 		return
 	}
 
 	if err := tx.Model(&models.User{}).Where("organization_id = ?", org.ID).Update("is_active", false).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate organization users"})
+		apierror.Respond(c, apierror.Internal("failed to deactivate organization users", err))
+		return
+	}
+
+	if err := events.RecordOrganization(tx, events.OrganizationDeactivated, org); err != nil {
+		tx.Rollback()
+		apierror.Respond(c, apierror.Internal("failed to record organization event", err))
 		return
 	}
 
 	if org.Type == models.OrgTypeContractor {
+		var activeDrivers []models.Driver
+		if err := tx.Where("contractor_id = ? AND is_active = ?", org.ID, true).Find(&activeDrivers).Error; err != nil {
+			tx.Rollback()
+			apierror.Respond(c, apierror.Internal("failed to fetch drivers", err))
+			return
+		}
+
 		if err := tx.Model(&models.Driver{}).Where("contractor_id = ?", org.ID).Update("is_active", false).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate drivers"})
+			apierror.Respond(c, apierror.Internal("failed to deactivate drivers", err))
 			return
 		}
 
 		var driverIDs []uuid.UUID
 		if err := tx.Model(&models.Driver{}).Where("contractor_id = ?", org.ID).Pluck("id", &driverIDs).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch driver ids"})
+			apierror.Respond(c, apierror.Internal("failed to fetch driver ids", err))
 			return
 		}
 
 		if len(driverIDs) > 0 {
 			if err := tx.Model(&models.User{}).Where("driver_id IN ?", driverIDs).Update("is_active", false).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate driver users"})
+				apierror.Respond(c, apierror.Internal("failed to deactivate driver users", err))
+				return
+			}
+		}
+
+		for _, driver := range activeDrivers {
+			driver.IsActive = false
+			if err := events.RecordDriver(tx, events.DriverDeactivated, driver); err != nil {
+				tx.Rollback()
+				apierror.Respond(c, apierror.Internal("failed to record driver event", err))
 				return
 			}
 		}
@@ -422,7 +504,7 @@ func DeleteOrganization(c *gin.Context) {
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to finalize organization deletion"})
+		apierror.Respond(c, apierror.Internal("failed to finalize organization deletion", err))
 		return
 	}
 
@@ -434,17 +516,17 @@ func FindUser(c *gin.Context) {
 	login := c.Query("login")
 
 	if phone == "" && login == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "phone or login required"})
+		apierror.Respond(c, apierror.New(apierror.CodeMissingQueryParameter).WithMessage("phone or login required"))
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
 	var user models.User
-	q := database.DB.Model(&models.User{}).Where("is_active = ?", true)
+	q := database.WithContext(c.Request.Context()).Model(&models.User{}).Where("is_active = ?", true)
 
 	if phone != "" {
 		q = q.Where("phone = ?", phone)
@@ -455,62 +537,66 @@ func FindUser(c *gin.Context) {
 
 	if err := q.First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeUserNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed"})
+			apierror.Respond(c, apierror.Internal("db query failed", err))
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	c.JSON(http.StatusOK, gin.H{"user": toUserDTO(user)})
 }
 
 func GetUser(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "not implemented yet"})
+	apierror.Respond(c, apierror.New(apierror.CodeNotImplemented))
 }
 
 func UpdateUser(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "not implemented yet"})
+	apierror.Respond(c, apierror.New(apierror.CodeNotImplemented))
 }
 
 func ListDrivers(c *gin.Context) {
-	c.JSON(200, gin.H{"message": "not implemented yet"})
+	apierror.Respond(c, apierror.New(apierror.CodeNotImplemented))
 }
 
 func CreateDriver(c *gin.Context) {
-	role := c.GetString("currentUserRole")
-	currentOrgID := c.GetString("currentOrgID")
-
-	if role == "" || currentOrgID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	role, contractorUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
 	if role != models.RoleContractorAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
 	var req CreateDriverRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, apierror.FromBinding(err))
 		return
 	}
 
-	contractorUUID, err := uuid.Parse(currentOrgID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid current organization id"})
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
-	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+	conflict, err := findDriverConflict(database.WithContext(c.Request.Context()), req.IIN, req.Phone, nil, true)
+	if err == nil {
+		conflict, err = scopeConflict(database.WithContext(c.Request.Context()), role, contractorUUID, conflict)
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check driver uniqueness", err))
+		return
+	}
+	if conflict != nil {
+		respondConflict(c, conflict)
 		return
 	}
 
-	tx := database.DB.Begin()
+	tx := database.WithContext(c.Request.Context()).Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start transaction"})
+		apierror.Respond(c, apierror.Internal("failed to start transaction", tx.Error))
 		return
 	}
 	defer func() {
@@ -532,7 +618,11 @@ func CreateDriver(c *gin.Context) {
 
 	if err := tx.Create(&driver).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create driver"})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			respondDriverDuplicate(c, database.WithContext(c.Request.Context()), role, contractorUUID, req.IIN, req.Phone, nil)
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to create driver", err))
 		return
 	}
 
@@ -547,93 +637,100 @@ func CreateDriver(c *gin.Context) {
 
 	if err := tx.Create(&user).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create driver user"})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			respondDriverDuplicate(c, database.WithContext(c.Request.Context()), role, contractorUUID, "", req.Phone, nil)
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to create driver user", err))
+		return
+	}
+
+	if err := events.RecordDriver(tx, events.DriverCreated, driver); err != nil {
+		tx.Rollback()
+		apierror.Respond(c, apierror.Internal("failed to record driver event", err))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit transaction"})
+		apierror.Respond(c, apierror.Internal("failed to commit transaction", err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"driver": driver,
-		"user": gin.H{
-			"id":             user.ID,
-			"phone":          user.Phone,
-			"role":           user.Role,
-			"organizationID": user.OrganizationID,
-			"driverID":       user.DriverID,
-			"isActive":       user.IsActive,
-			"createdAt":      user.CreatedAt,
-			"updatedAt":      user.UpdatedAt,
-		},
+		"driver": toDriverDTO(driver, models.EvaluateDriverClearance(driver, nil, time.Now())),
+		"user":   toUserDTO(user),
 	})
 }
 
 func GetDriver(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
-	id := c.Param("id")
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+		return
+	}
 
 	var driver models.Driver
-	if err := database.DB.Where("id = ? AND is_active = ?", id, true).First(&driver).Error; err != nil {
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND is_active = ?", id, true).First(&driver).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed"})
+			apierror.Respond(c, apierror.Internal("db query failed", err))
 		}
 		return
 	}
 
-	role := c.GetString("currentUserRole")
-	orgID := c.GetString("currentOrgID")
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
 
-	contractorOrgID := ""
-	if driver.ContractorID != nil {
-		contractorOrgID = driver.ContractorID.String()
+	if !authorizeDriver(c, role, currentOrgUUID, driver) {
+		return
 	}
 
-	if !CanAccessDriver(role, orgID, contractorOrgID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	clearance, err := driverClearance(database.WithContext(c.Request.Context()), driver)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to evaluate driver clearance", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"driver": driver})
+	c.JSON(http.StatusOK, gin.H{"driver": toDriverDTO(driver, clearance)})
 }
 
 func UpdateDriver(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
-	id := c.Param("id")
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+		return
+	}
 
 	var driver models.Driver
-	if err := database.DB.Where("id = ?", id).First(&driver).Error; err != nil {
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", id).First(&driver).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed"})
+			apierror.Respond(c, apierror.Internal("db query failed", err))
 		}
 		return
 	}
 
-	role := c.GetString("currentUserRole")
-	orgID := c.GetString("currentOrgID")
-
-	contractorOrgID := ""
-	if driver.ContractorID != nil {
-		contractorOrgID = driver.ContractorID.String()
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
 	}
 
-	if !CanAccessDriver(role, orgID, contractorOrgID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if !authorizeDriver(c, role, currentOrgUUID, driver) {
 		return
 	}
 
@@ -645,76 +742,277 @@ func UpdateDriver(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		apierror.Respond(c, apierror.FromBinding(err))
 		return
 	}
 
-	if err := database.DB.Model(&driver).Updates(body).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db update failed"})
+	if driver.IsActive {
+		iin, phone := "", ""
+		if body.IIN != nil && *body.IIN != driver.IIN {
+			iin = *body.IIN
+		}
+		if body.Phone != nil && *body.Phone != driver.Phone {
+			phone = *body.Phone
+		}
+
+		conflict, err := findDriverConflict(database.WithContext(c.Request.Context()), iin, phone, &driver.ID, false)
+		if err == nil {
+			conflict, err = scopeConflict(database.WithContext(c.Request.Context()), role, currentOrgUUID, conflict)
+		}
+		if err != nil {
+			apierror.Respond(c, apierror.Internal("db query failed", err))
+			return
+		}
+		if conflict != nil {
+			respondConflict(c, conflict)
+			return
+		}
+	}
+
+	phoneChanged := body.Phone != nil && *body.Phone != driver.Phone
+
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&driver).Updates(body).Error; err != nil {
+			return err
+		}
+		// Телефон водителя — это и логин его учётной записи.
+		if phoneChanged {
+			if err := tx.Model(&models.User{}).Where("driver_id = ?", driver.ID).Update("phone", *body.Phone).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id = ?", id).First(&driver).Error; err != nil {
+			return err
+		}
+		return events.RecordDriver(tx, events.DriverUpdated, driver)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			iin, phone := "", ""
+			if body.IIN != nil {
+				iin = *body.IIN
+			}
+			if body.Phone != nil {
+				phone = *body.Phone
+			}
+			respondDriverDuplicate(c, database.WithContext(c.Request.Context()), role, currentOrgUUID, iin, phone, &driver.ID)
+			return
+		}
+		apierror.Respond(c, apierror.Internal("db update failed", err))
 		return
 	}
 
-	if err := database.DB.Where("id = ?", id).First(&driver).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed"})
+	clearance, err := driverClearance(database.WithContext(c.Request.Context()), driver)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to evaluate driver clearance", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"driver": driver})
+	c.JSON(http.StatusOK, gin.H{"driver": toDriverDTO(driver, clearance)})
 }
 
 func DeleteDriver(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database not initialized"})
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
 		return
 	}
 
-	id := c.Param("id")
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+		return
+	}
 
 	var driver models.Driver
-	if err := database.DB.Where("id = ?", id).First(&driver).Error; err != nil {
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", id).First(&driver).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "driver not found"})
+			apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed"})
+			apierror.Respond(c, apierror.Internal("db query failed", err))
 		}
 		return
 	}
 
-	role := c.GetString("currentUserRole")
-	orgID := c.GetString("currentOrgID")
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
 
-	contractorOrgID := ""
-	if driver.ContractorID != nil {
-		contractorOrgID = driver.ContractorID.String()
+	if !authorizeDriver(c, role, currentOrgUUID, driver) {
+		return
 	}
 
-	if !CanAccessDriver(role, orgID, contractorOrgID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&driver).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("driver_id = ?", driver.ID).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return events.RecordDriver(tx, events.DriverDeactivated, driver)
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("db update failed", err))
 		return
 	}
 
-	if err := database.DB.Model(&driver).Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db update failed"})
+	c.Status(http.StatusNoContent)
+}
+
+// RehireDriver повторно принимает неактивного водителя в организацию текущего подрядчика
+// вместо создания дубликата с тем же ИИН. Водителя другого подрядчика можно принять,
+// только подтвердив его ИИН; без этого он считается ненайденным.
+func RehireDriver(c *gin.Context) {
+	role, contractorUUID, ok := requireCurrentOrg(c)
+	if !ok {
 		return
 	}
 
-	if err := database.DB.Model(&models.User{}).Where("driver_id = ?", driver.ID).Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db update failed"})
+	if role != models.RoleContractorAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
 		return
 	}
 
-	c.Status(http.StatusNoContent)
-}
+	var body struct {
+		IIN   string  `json:"iin"`
+		Phone *string `json:"phone"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			apierror.Respond(c, apierror.FromBinding(err))
+			return
+		}
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	driverUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+		return
+	}
+
+	var driver models.Driver
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", driverUUID).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("db query failed", err))
+		}
+		return
+	}
+
+	ownDriver := driver.ContractorID != nil && *driver.ContractorID == contractorUUID
+	if !ownDriver && subtle.ConstantTimeCompare([]byte(body.IIN), []byte(driver.IIN)) != 1 {
+		apierror.Respond(c, apierror.New(apierror.CodeDriverNotFound))
+		return
+	}
+
+	if driver.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeDriverAlreadyActive))
+		return
+	}
 
-func CanAccessDriver(role, currentOrgID, contractorOrgID string) bool {
-	if role == "AKIMAT_ADMIN" {
-		return true
+	phone := driver.Phone
+	if body.Phone != nil && *body.Phone != "" {
+		phone = *body.Phone
 	}
-	if role == "TOO_ADMIN" && contractorOrgID != "" {
-		return true
+
+	conflict, err := findDriverConflict(database.WithContext(c.Request.Context()), driver.IIN, phone, &driver.ID, false)
+	if err == nil {
+		conflict, err = scopeConflict(database.WithContext(c.Request.Context()), role, contractorUUID, conflict)
+	}
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check driver uniqueness", err))
+		return
+	}
+	if conflict != nil {
+		respondConflict(c, conflict)
+		return
 	}
-	if role == "CONTRACTOR_ADMIN" && currentOrgID == contractorOrgID {
-		return true
+
+	tx := database.WithContext(c.Request.Context()).Begin()
+	if tx.Error != nil {
+		apierror.Respond(c, apierror.Internal("failed to start transaction", tx.Error))
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	contractorID := contractorUUID
+	if err := tx.Model(&driver).Updates(map[string]interface{}{
+		"contractor_id": contractorID,
+		"phone":         phone,
+		"is_active":     true,
+	}).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			respondDriverDuplicate(c, database.WithContext(c.Request.Context()), role, contractorUUID, driver.IIN, phone, &driver.ID)
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to rehire driver", err))
+		return
 	}
-	return false
+
+	var user models.User
+	err = tx.Where("driver_id = ?", driver.ID).Order("updated_at DESC").First(&user).Error
+	switch {
+	case err == nil:
+		// Учётная запись могла сменить роль или организацию, пока водитель был уволен.
+		err = tx.Model(&user).Updates(map[string]interface{}{
+			"phone":           phone,
+			"role":            models.RoleDriver,
+			"organization_id": contractorID,
+			"is_active":       true,
+		}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		driverID := driver.ID
+		user = models.User{
+			Phone:          phone,
+			Role:           models.RoleDriver,
+			OrganizationID: &contractorID,
+			DriverID:       &driverID,
+			IsActive:       true,
+		}
+		err = tx.Create(&user).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			respondDriverDuplicate(c, database.WithContext(c.Request.Context()), role, contractorUUID, "", phone, &driver.ID)
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to restore driver user", err))
+		return
+	}
+
+	if err := events.RecordDriver(tx, events.DriverRehired, driver); err != nil {
+		tx.Rollback()
+		apierror.Respond(c, apierror.Internal("failed to record driver event", err))
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		apierror.Respond(c, apierror.Internal("failed to commit transaction", err))
+		return
+	}
+
+	clearance, err := driverClearance(database.WithContext(c.Request.Context()), driver)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to evaluate driver clearance", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"driver": toDriverDTO(driver, clearance),
+		"user":   toUserDTO(user),
+	})
 }
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// contractorScope возвращает подрядчиков, данные которых доступны роли. Пустой
+// срез без ошибки означает, что у роли нет подрядчиков в области видимости;
+// nil означает отсутствие ограничения (администратор акимата без фильтра).
+// Если requested задан, область сужается до него после проверки доступа.
+func contractorScope(db *gorm.DB, role string, currentOrgID uuid.UUID, requested *uuid.UUID) ([]uuid.UUID, *apierror.Error) {
+	switch role {
+	case models.RoleAkimatAdmin:
+		if requested != nil {
+			return []uuid.UUID{*requested}, nil
+		}
+		return nil, nil
+	case models.RoleTooAdmin:
+		var contractorIDs []uuid.UUID
+		if err := db.Model(&models.Organization{}).
+			Where("parent_org_id = ? AND type = ? AND is_active = ?", currentOrgID, models.OrgTypeContractor, true).
+			Pluck("id", &contractorIDs).Error; err != nil {
+			return nil, apierror.Internal("failed to fetch contractor organizations", err)
+		}
+		if requested == nil {
+			if contractorIDs == nil {
+				contractorIDs = []uuid.UUID{}
+			}
+			return contractorIDs, nil
+		}
+		for _, id := range contractorIDs {
+			if id == *requested {
+				return []uuid.UUID{id}, nil
+			}
+		}
+		return nil, apierror.New(apierror.CodeForbiddenScope)
+	case models.RoleContractorAdmin:
+		if requested != nil && *requested != currentOrgID {
+			return nil, apierror.New(apierror.CodeForbiddenScope)
+		}
+		return []uuid.UUID{currentOrgID}, nil
+	default:
+		return nil, apierror.New(apierror.CodeForbidden)
+	}
+}
+
+// requireCurrentOrg возвращает роль и организацию текущего пользователя; без
+// аутентифицированного пользователя отвечает 401.
+func requireCurrentOrg(c *gin.Context) (string, uuid.UUID, bool) {
+	principal, ok := auth.Require(c)
+	if !ok {
+		return "", uuid.Nil, false
+	}
+	return principal.Role, principal.OrganizationID, true
+}
+
+// canAccessContractor проверяет, входит ли подрядчик в область видимости роли:
+// акимат видит всех, ТОО — своих подрядчиков, подрядчик — только себя.
+func canAccessContractor(db *gorm.DB, role string, currentOrgID uuid.UUID, contractorID *uuid.UUID) (bool, error) {
+	switch role {
+	case models.RoleAkimatAdmin:
+		return true, nil
+	case models.RoleTooAdmin:
+		if contractorID == nil {
+			return false, nil
+		}
+		var count int64
+		if err := db.Model(&models.Organization{}).
+			Where("id = ? AND parent_org_id = ?", *contractorID, currentOrgID).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	case models.RoleContractorAdmin:
+		return contractorID != nil && *contractorID == currentOrgID, nil
+	default:
+		return false, nil
+	}
+}
+
+// authorizeDriver проверяет, что водитель принадлежит подрядчику из области
+// видимости роли; иначе отвечает FORBIDDEN_SCOPE.
+func authorizeDriver(c *gin.Context, role string, currentOrgID uuid.UUID, driver models.Driver) bool {
+	allowed, err := canAccessContractor(database.WithContext(c.Request.Context()), role, currentOrgID, driver.ContractorID)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check driver access", err))
+		return false
+	}
+	if !allowed {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return false
+	}
+	return true
+}
+
+// parseContractorQuery читает необязательный фильтр contractor_id из строки запроса.
+func parseContractorQuery(c *gin.Context) (*uuid.UUID, *apierror.Error) {
+	raw := c.Query("contractor_id")
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, apierror.New(apierror.CodeInvalidID).WithMessage("invalid organization id")
+	}
+	return &id, nil
+}
+
+// routeResources сопоставляет раздел API (первый сегмент пути после базового)
+// ресурсу разрешений служебных учётных записей. Разделы вне списка доступны
+// только пользователям.
+var routeResources = map[string]string{
+	"contracts":         "contracts",
+	"organizations":     "organizations",
+	"users":             "users",
+	"drivers":           "drivers",
+	"driver-documents":  "drivers",
+	"vehicles":          "vehicles",
+	"vehicle-documents": "vehicles",
+	"service-areas":     "service_areas",
+	"shifts":            "shifts",
+	"passes":            "passes",
+	"vehicle-types":     "",
+	"vehicle-capacity":  "",
+}
+
+// RequireRouteScope проверяет разрешения субъекта для раздела API: GET требует
+// <ресурс>:read, остальные методы — <ресурс>:write. Справочники доступны любому
+// субъекту, пользователи с полным набором разрешений проходят всегда.
+func RequireRouteScope(basePath string) gin.HandlerFunc {
+	basePath = strings.TrimSuffix(basePath, "/") + "/"
+	return func(c *gin.Context) {
+		principal, ok := auth.Require(c)
+		if !ok {
+			return
+		}
+		if principal.HasScope(auth.ScopeAll) {
+			c.Next()
+			return
+		}
+
+		section := strings.TrimPrefix(c.FullPath(), basePath)
+		section, _, _ = strings.Cut(section, "/")
+		resource, known := routeResources[section]
+		if !known {
+			apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+			return
+		}
+		if resource != "" {
+			action := "write"
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				action = "read"
+			}
+			if !principal.HasScope(resource + ":" + action) {
+				apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope).With("required_scope", resource+":"+action))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
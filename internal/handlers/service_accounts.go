@@ -0,0 +1,604 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// Срок действия API-ключа по умолчанию и наибольший допустимый, в днях.
+const (
+	defaultAPIKeyLifetimeDays = 90
+	maxAPIKeyLifetimeDays     = 365
+)
+
+// ServiceAccountRequest описывает служебную учётную запись. Без organization_id
+// запись создаётся в организации текущего пользователя.
+type ServiceAccountRequest struct {
+	Name           string     `json:"name" binding:"required,max=255"`
+	Description    string     `json:"description" binding:"max=1000"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	Scopes         []string   `json:"scopes" binding:"required,min=1"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// CreateAPIKeyRequest описывает новый ключ; без expires_in_days ключ действует 90 дней.
+type CreateAPIKeyRequest struct {
+	Name          string `json:"name" binding:"max=255"`
+	ExpiresInDays *int   `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// RotateAPIKeyRequest задаёт срок, в течение которого прежний ключ ещё
+// действует, чтобы внешняя система успела перейти на новый.
+type RotateAPIKeyRequest struct {
+	ExpiresInDays    *int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	GracePeriodHours int  `json:"grace_period_hours" binding:"min=0,max=168"`
+}
+
+// ServiceAccountDTO — служебная учётная запись в ответах API.
+type ServiceAccountDTO struct {
+	ID             uuid.UUID   `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+	Scopes         []string    `json:"scopes"`
+	IsActive       bool        `json:"is_active"`
+	Keys           []APIKeyDTO `json:"keys,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// APIKeyDTO — API-ключ в ответах API. Key заполняется только при создании и
+// ротации: в базе хранится лишь хэш.
+type APIKeyDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Key         string     `json:"key,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	RotatedToID *uuid.UUID `json:"rotated_to_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func toServiceAccountDTO(account models.ServiceAccount) ServiceAccountDTO {
+	scopes := account.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return ServiceAccountDTO{
+		ID:             account.ID,
+		Name:           account.Name,
+		Description:    account.Description,
+		OrganizationID: account.OrganizationID,
+		Scopes:         scopes,
+		IsActive:       account.IsActive,
+		CreatedAt:      account.CreatedAt,
+		UpdatedAt:      account.UpdatedAt,
+	}
+}
+
+func toAPIKeyDTO(key models.APIKey) APIKeyDTO {
+	return APIKeyDTO{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+		RotatedToID: key.RotatedToID,
+		CreatedAt:   key.CreatedAt,
+	}
+}
+
+// canManageServiceAccounts проверяет, может ли роль управлять служебными
+// учётными записями организации: акимат — любыми, ТОО — своими и своих
+// подрядчиков, подрядчик — только своими.
+func canManageServiceAccounts(db *gorm.DB, role string, currentOrgID, orgID uuid.UUID) (bool, error) {
+	switch role {
+	case models.RoleAkimatAdmin, models.RoleTooAdmin, models.RoleContractorAdmin:
+		if orgID == currentOrgID {
+			return true, nil
+		}
+		return canAccessContractor(db, role, currentOrgID, &orgID)
+	default:
+		return false, nil
+	}
+}
+
+// validateServiceAccountScopes проверяет, что все разрешения можно выдать.
+func validateServiceAccountScopes(scopes []string) *apierror.Error {
+	for _, scope := range scopes {
+		if !models.IsServiceAccountScope(scope) {
+			return apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "scopes", Rule: "oneof", Param: strings.Join(models.ServiceAccountScopes, " ")})
+		}
+	}
+	return nil
+}
+
+// createdBy возвращает пользователя, выполняющего запрос.
+func createdBy(c *gin.Context) *uuid.UUID {
+	principal, ok := auth.FromGin(c)
+	if !ok || principal.IsServiceAccount() {
+		return nil
+	}
+	userID := principal.UserID
+	return &userID
+}
+
+// newAPIKey выпускает ключ служебной учётной записи со сроком действия в днях.
+func newAPIKey(c *gin.Context, accountID uuid.UUID, name string, expiresInDays *int) (models.APIKey, string, error) {
+	generated, err := auth.GenerateAPIKey()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	days := defaultAPIKeyLifetimeDays
+	if expiresInDays != nil {
+		days = *expiresInDays
+	}
+
+	return models.APIKey{
+		ServiceAccountID: accountID,
+		Name:             name,
+		Prefix:           generated.Prefix,
+		Hash:             generated.Hash,
+		ExpiresAt:        time.Now().AddDate(0, 0, days),
+		CreatedByID:      createdBy(c),
+	}, generated.Raw, nil
+}
+
+// loadServiceAccount загружает служебную учётную запись из параметра :id и
+// проверяет право управлять ею.
+func loadServiceAccount(c *gin.Context) (*models.ServiceAccount, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid service account id"))
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	var account models.ServiceAccount
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", id).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeServiceAccountNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch service account", err))
+		}
+		return nil, false
+	}
+
+	allowed, err := canManageServiceAccounts(database.WithContext(c.Request.Context()), role, currentOrgUUID, account.OrganizationID)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check service account access", err))
+		return nil, false
+	}
+	if !allowed {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return nil, false
+	}
+
+	return &account, true
+}
+
+// loadAPIKey загружает ключ из параметра :keyId в пределах учётной записи.
+func loadAPIKey(c *gin.Context, account *models.ServiceAccount) (*models.APIKey, bool) {
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid API key id"))
+		return nil, false
+	}
+
+	var key models.APIKey
+	if err := database.WithContext(c.Request.Context()).
+		Where("id = ? AND service_account_id = ?", keyID, account.ID).
+		First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeAPIKeyNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch API key", err))
+		}
+		return nil, false
+	}
+
+	return &key, true
+}
+
+// ListServiceAccounts возвращает служебные учётные записи организаций в
+// области видимости пользователя.
+func ListServiceAccounts(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	orgIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, nil)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	q := database.WithContext(c.Request.Context()).Model(&models.ServiceAccount{})
+	if orgIDs != nil {
+		if role == models.RoleTooAdmin {
+			orgIDs = append(orgIDs, currentOrgUUID)
+		}
+		q = q.Where("organization_id IN ?", orgIDs)
+	}
+
+	var accounts []models.ServiceAccount
+	if err := q.Order("created_at").Find(&accounts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch service accounts", err))
+		return
+	}
+
+	result := make([]ServiceAccountDTO, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, toServiceAccountDTO(account))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_accounts": result})
+}
+
+// CreateServiceAccount создаёт служебную учётную запись вместе с первым
+// ключом; ключ возвращается один раз.
+func CreateServiceAccount(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	var req ServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+	if apiErr := validateServiceAccountScopes(req.Scopes); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	orgID := currentOrgUUID
+	if req.OrganizationID != nil {
+		orgID = *req.OrganizationID
+	}
+
+	allowed, err := canManageServiceAccounts(database.WithContext(c.Request.Context()), role, currentOrgUUID, orgID)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check service account access", err))
+		return
+	}
+	if !allowed {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return
+	}
+
+	var count int64
+	if err := database.WithContext(c.Request.Context()).Model(&models.Organization{}).
+		Where("id = ? AND is_active = ?", orgID, true).
+		Count(&count).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
+		return
+	}
+	if count == 0 {
+		apierror.Respond(c, apierror.New(apierror.CodeOrgNotFound))
+		return
+	}
+
+	account := models.ServiceAccount{
+		Name:           req.Name,
+		Description:    req.Description,
+		OrganizationID: orgID,
+		Scopes:         req.Scopes,
+		CreatedByID:    createdBy(c),
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+
+	var key models.APIKey
+	var raw string
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return err
+		}
+		// IsActive=false не записывается через Create из-за значения по умолчанию.
+		if !account.IsActive {
+			if err := tx.Model(&account).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+
+		var err error
+		key, raw, err = newAPIKey(c, account.ID, req.Name, nil)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&key).Error
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create service account", err))
+		return
+	}
+
+	keyDTO := toAPIKeyDTO(key)
+	keyDTO.Key = raw
+	dto := toServiceAccountDTO(account)
+	dto.Keys = []APIKeyDTO{keyDTO}
+	c.JSON(http.StatusCreated, gin.H{"service_account": dto})
+}
+
+// GetServiceAccount возвращает учётную запись со всеми её ключами, включая
+// отозванные.
+func GetServiceAccount(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var keys []models.APIKey
+	if err := database.WithContext(c.Request.Context()).
+		Where("service_account_id = ?", account.ID).
+		Order("created_at").
+		Find(&keys).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch API keys", err))
+		return
+	}
+
+	dto := toServiceAccountDTO(*account)
+	dto.Keys = make([]APIKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		dto.Keys = append(dto.Keys, toAPIKeyDTO(key))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_account": dto})
+}
+
+// InvalidateAPIKey сбрасывает закэшированную проверку ключа по префиксу после
+// фиксации изменений. Функцию задают при старте сервиса в режиме jwt; сброс
+// действует в этом экземпляре, остальные увидят изменения в течение
+// AUTH_IDENTITY_CACHE_TTL.
+var InvalidateAPIKey = func(prefix string) {}
+
+// invalidateAccountKeys сбрасывает кэш всех ключей учётной записи.
+func invalidateAccountKeys(c *gin.Context, accountID uuid.UUID) {
+	var prefixes []string
+	if err := database.WithContext(c.Request.Context()).Model(&models.APIKey{}).
+		Where("service_account_id = ?", accountID).
+		Pluck("prefix", &prefixes).Error; err != nil {
+		// Кэш истечёт сам через AUTH_IDENTITY_CACHE_TTL.
+		slog.WarnContext(c.Request.Context(), "не удалось сбросить кэш ключей служебной учётной записи",
+			"service_account_id", accountID, "error", err)
+		return
+	}
+	for _, prefix := range prefixes {
+		InvalidateAPIKey(prefix)
+	}
+}
+
+// UpdateServiceAccount меняет название, описание, разрешения и активность.
+// Организацию учётной записи сменить нельзя. В этом экземпляре сервиса изменения
+// действуют сразу, в остальных — в течение AUTH_IDENTITY_CACHE_TTL.
+func UpdateServiceAccount(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var req ServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+	if apiErr := validateServiceAccountScopes(req.Scopes); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	if req.OrganizationID != nil && *req.OrganizationID != account.OrganizationID {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "organization_id", Rule: "eq", Param: account.OrganizationID.String()}))
+		return
+	}
+
+	account.Name = req.Name
+	account.Description = req.Description
+	account.Scopes = req.Scopes
+	if req.IsActive != nil {
+		account.IsActive = *req.IsActive
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(account).
+		Select("name", "description", "scopes", "is_active").
+		Updates(account).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to update service account", err))
+		return
+	}
+	invalidateAccountKeys(c, account.ID)
+
+	c.JSON(http.StatusOK, gin.H{"service_account": toServiceAccountDTO(*account)})
+}
+
+// DeleteServiceAccount отключает учётную запись и отзывает все её ключи.
+func DeleteServiceAccount(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	err := database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(account).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", account.ID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to deactivate service account", err))
+		return
+	}
+	invalidateAccountKeys(c, account.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateAPIKey выпускает дополнительный ключ учётной записи; ключ возвращается один раз.
+func CreateAPIKey(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, apierror.FromBinding(err))
+			return
+		}
+	}
+
+	if !account.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeServiceAccountNotFound).WithMessage("service account is inactive"))
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = account.Name
+	}
+
+	key, raw, err := newAPIKey(c, account.ID, name, req.ExpiresInDays)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to generate API key", err))
+		return
+	}
+	if err := database.WithContext(c.Request.Context()).Create(&key).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create API key", err))
+		return
+	}
+
+	dto := toAPIKeyDTO(key)
+	dto.Key = raw
+	c.JSON(http.StatusCreated, gin.H{"key": dto})
+}
+
+// RotateAPIKey выпускает ключ взамен действующего. Прежний ключ отзывается
+// сразу или, если задан grace_period_hours, продолжает действовать указанное
+// время.
+func RotateAPIKey(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	key, ok := loadAPIKey(c, account)
+	if !ok {
+		return
+	}
+
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, apierror.FromBinding(err))
+			return
+		}
+	}
+
+	if !account.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeServiceAccountNotFound).WithMessage("service account is inactive"))
+		return
+	}
+	now := time.Now()
+	if !key.IsUsable(now) || key.RotatedToID != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeAPIKeyNotFound).WithMessage("API key is revoked or expired"))
+		return
+	}
+
+	replacement, raw, err := newAPIKey(c, account.ID, key.Name, req.ExpiresInDays)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to generate API key", err))
+		return
+	}
+
+	err = database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&replacement).Error; err != nil {
+			return err
+		}
+
+		key.RotatedToID = &replacement.ID
+		updates := map[string]any{"rotated_to_id": replacement.ID}
+		if req.GracePeriodHours == 0 {
+			key.RevokedAt = &now
+			updates["revoked_at"] = now
+		} else if graceEnd := now.Add(time.Duration(req.GracePeriodHours) * time.Hour); graceEnd.Before(key.ExpiresAt) {
+			key.ExpiresAt = graceEnd
+			updates["expires_at"] = graceEnd
+		}
+		return tx.Model(key).Updates(updates).Error
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to rotate API key", err))
+		return
+	}
+	InvalidateAPIKey(key.Prefix)
+
+	dto := toAPIKeyDTO(replacement)
+	dto.Key = raw
+	c.JSON(http.StatusOK, gin.H{"key": dto, "previous_key": toAPIKeyDTO(*key)})
+}
+
+// RevokeAPIKey отзывает ключ. В этом экземпляре сервиса отзыв действует сразу,
+// в остальных — в течение AUTH_IDENTITY_CACHE_TTL.
+func RevokeAPIKey(c *gin.Context) {
+	account, ok := loadServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	key, ok := loadAPIKey(c, account)
+	if !ok {
+		return
+	}
+
+	if key.RevokedAt == nil {
+		if err := database.WithContext(c.Request.Context()).Model(key).Update("revoked_at", time.Now()).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to revoke API key", err))
+			return
+		}
+		InvalidateAPIKey(key.Prefix)
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,545 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/geo"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// ServiceAreaRequest описывает участок уборки. Организация задаётся только при создании.
+type ServiceAreaRequest struct {
+	Name           string          `json:"name" binding:"required"`
+	District       string          `json:"district"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	Boundary       json.RawMessage `json:"boundary" binding:"required"`
+}
+
+// ServiceAreaDTO — представление участка в ответах API.
+type ServiceAreaDTO struct {
+	ID             uuid.UUID       `json:"id"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	ParentAreaID   *uuid.UUID      `json:"parent_area_id"`
+	Name           string          `json:"name"`
+	District       string          `json:"district"`
+	Boundary       json.RawMessage `json:"boundary"`
+	IsActive       bool            `json:"is_active"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// AreaCoverageDTO — участок, покрывающий точку, вместе с закреплённой организацией.
+type AreaCoverageDTO struct {
+	Area         ServiceAreaDTO  `json:"area"`
+	Organization OrganizationDTO `json:"organization"`
+}
+
+func toServiceAreaDTO(area models.ServiceArea) ServiceAreaDTO {
+	return ServiceAreaDTO{
+		ID:             area.ID,
+		OrganizationID: area.OrganizationID,
+		ParentAreaID:   area.ParentAreaID,
+		Name:           area.Name,
+		District:       area.District,
+		Boundary:       json.RawMessage(area.Boundary),
+		IsActive:       area.IsActive,
+		CreatedAt:      area.CreatedAt,
+		UpdatedAt:      area.UpdatedAt,
+	}
+}
+
+// parseBoundary разбирает границы участка из запроса.
+func parseBoundary(raw json.RawMessage) (geo.Polygon, *apierror.Error) {
+	polygon, err := geo.ParsePolygon(raw)
+	if err != nil {
+		return geo.Polygon{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "boundary", Rule: "geojson_polygon"})
+	}
+	return polygon, nil
+}
+
+// areaPolygon разбирает сохранённые границы участка.
+func areaPolygon(area models.ServiceArea) (geo.Polygon, error) {
+	return geo.ParsePolygon([]byte(area.Boundary))
+}
+
+// checkAreaOwner проверяет, может ли роль закреплять участки за организацией:
+// акимат — за активным ТОО, ТОО — за собственным активным подрядчиком.
+func checkAreaOwner(db *gorm.DB, role string, currentOrgID, orgID uuid.UUID) (*models.Organization, *apierror.Error) {
+	var org models.Organization
+	if err := db.Where("id = ? AND is_active = ?", orgID, true).First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.New(apierror.CodeOrgNotFound)
+		}
+		return nil, apierror.Internal("failed to fetch organization", err)
+	}
+
+	switch role {
+	case models.RoleAkimatAdmin:
+		if org.Type == models.OrgTypeToo {
+			return &org, nil
+		}
+	case models.RoleTooAdmin:
+		if org.Type == models.OrgTypeContractor && org.ParentOrgID != nil && *org.ParentOrgID == currentOrgID {
+			return &org, nil
+		}
+	default:
+		return nil, apierror.New(apierror.CodeForbidden)
+	}
+
+	return nil, apierror.New(apierror.CodeValidationFailed).
+		WithMessage("service areas can only be assigned to a TOO or your own contractor")
+}
+
+// tooAreaPolygons загружает активные участки ТОО, рамка которых пересекается с box.
+// Участок exclude пропускается: его заменяют новые границы или он удаляется.
+func tooAreaPolygons(db *gorm.DB, tooID uuid.UUID, box geo.BBox, exclude *uuid.UUID) ([]models.ServiceArea, []geo.Polygon, *apierror.Error) {
+	q := db.Where("organization_id = ? AND is_active = ?", tooID, true).
+		Where("min_lon <= ? AND min_lat <= ? AND max_lon >= ? AND max_lat >= ?", box.MaxLon, box.MaxLat, box.MinLon, box.MinLat)
+	if exclude != nil {
+		q = q.Where("id <> ?", *exclude)
+	}
+
+	var candidates []models.ServiceArea
+	if err := q.Order("created_at").Find(&candidates).Error; err != nil {
+		return nil, nil, apierror.Internal("failed to fetch service areas", err)
+	}
+
+	areas := make([]models.ServiceArea, 0, len(candidates))
+	polygons := make([]geo.Polygon, 0, len(candidates))
+	for _, candidate := range candidates {
+		polygon, err := areaPolygon(candidate)
+		if err != nil {
+			continue
+		}
+		areas = append(areas, candidate)
+		polygons = append(polygons, polygon)
+	}
+	return areas, polygons, nil
+}
+
+// findParentArea проверяет, что полигон целиком лежит в объединении активных участков
+// ТОО, и возвращает участок, к которому его относят: содержащий его целиком или,
+// если полигон охватывает несколько участков, содержащий его первую вершину.
+func findParentArea(db *gorm.DB, tooID uuid.UUID, polygon geo.Polygon) (*models.ServiceArea, *apierror.Error) {
+	areas, polygons, apiErr := tooAreaPolygons(db, tooID, polygon.BBox(), nil)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !polygon.CoveredBy(polygons) {
+		return nil, apierror.New(apierror.CodeAreaOutsideParent)
+	}
+
+	for i := range areas {
+		if polygons[i].ContainsPolygon(polygon) {
+			return &areas[i], nil
+		}
+	}
+	for i := range areas {
+		if polygons[i].ContainsPoint(polygon.Outer[0]) {
+			return &areas[i], nil
+		}
+	}
+	return &areas[0], nil
+}
+
+// checkChildAreasInside проверяет, что участки подрядчиков ТОО остаются внутри
+// объединения его участков, если участок areaID получит границы polygon или,
+// при polygon == nil, будет удалён.
+func checkChildAreasInside(db *gorm.DB, tooID, areaID uuid.UUID, polygon *geo.Polygon) *apierror.Error {
+	var children []models.ServiceArea
+	if err := db.Where("is_active = ?", true).
+		Where("parent_area_id IN (?)", db.Model(&models.ServiceArea{}).Select("id").Where("organization_id = ?", tooID)).
+		Find(&children).Error; err != nil {
+		return apierror.Internal("failed to fetch service areas", err)
+	}
+
+	for _, child := range children {
+		childPolygon, err := areaPolygon(child)
+		if err != nil {
+			continue
+		}
+		_, parents, apiErr := tooAreaPolygons(db, tooID, childPolygon.BBox(), &areaID)
+		if apiErr != nil {
+			return apiErr
+		}
+		if polygon != nil {
+			parents = append(parents, *polygon)
+		}
+		if !childPolygon.CoveredBy(parents) {
+			return apierror.New(apierror.CodeAreaOutsideParent).
+				WithMessage("contractor areas would fall outside the TOO's areas")
+		}
+	}
+
+	return nil
+}
+
+// serviceAreaVisibility ограничивает запрос участками, видимыми роли: акимат видит все,
+// ТОО — свои и своих подрядчиков, подрядчик — только свои.
+func serviceAreaVisibility(db *gorm.DB, q *gorm.DB, role string, currentOrgID uuid.UUID) (*gorm.DB, *apierror.Error) {
+	switch role {
+	case models.RoleAkimatAdmin:
+		return q, nil
+	case models.RoleTooAdmin, models.RoleContractorAdmin:
+		orgIDs, apiErr := contractorScope(db, role, currentOrgID, nil)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if role == models.RoleTooAdmin {
+			orgIDs = append(orgIDs, currentOrgID)
+		}
+		return q.Where("service_areas.organization_id IN ?", orgIDs), nil
+	default:
+		return nil, apierror.New(apierror.CodeForbidden)
+	}
+}
+
+// loadAccessibleArea загружает активный участок из параметра :id. При manage = true
+// требуется право закреплять участки за организацией участка.
+func loadAccessibleArea(c *gin.Context, manage bool) (*models.ServiceArea, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	areaUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid service area id"))
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	q, apiErr := serviceAreaVisibility(database.WithContext(c.Request.Context()), database.WithContext(c.Request.Context()).Model(&models.ServiceArea{}), role, currentOrgUUID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return nil, false
+	}
+
+	var area models.ServiceArea
+	if err := q.Where("service_areas.id = ? AND service_areas.is_active = ?", areaUUID, true).First(&area).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeAreaNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch service area", err))
+		}
+		return nil, false
+	}
+
+	if manage {
+		if _, apiErr := checkAreaOwner(database.WithContext(c.Request.Context()), role, currentOrgUUID, area.OrganizationID); apiErr != nil {
+			if apiErr.Code == apierror.CodeValidationFailed {
+				apiErr = apierror.New(apierror.CodeForbiddenScope)
+			}
+			apierror.Respond(c, apiErr)
+			return nil, false
+		}
+	}
+
+	return &area, true
+}
+
+func ListServiceAreas(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	q, apiErr := serviceAreaVisibility(database.WithContext(c.Request.Context()), database.WithContext(c.Request.Context()).Model(&models.ServiceArea{}), role, currentOrgUUID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	q = q.Where("service_areas.is_active = ?", true)
+
+	if raw := c.Query("organization_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid organization id"))
+			return
+		}
+		q = q.Where("service_areas.organization_id = ?", id)
+	}
+	if district := c.Query("district"); district != "" {
+		q = q.Where("service_areas.district = ?", district)
+	}
+
+	var areas []models.ServiceArea
+	if err := q.Order("service_areas.district, service_areas.name").Find(&areas).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch service areas", err))
+		return
+	}
+
+	result := make([]ServiceAreaDTO, 0, len(areas))
+	for _, area := range areas {
+		result = append(result, toServiceAreaDTO(area))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_areas": result})
+}
+
+func CreateServiceArea(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if role != models.RoleAkimatAdmin && role != models.RoleTooAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	var req ServiceAreaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if req.OrganizationID == uuid.Nil {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).WithDetails(apierror.FieldRequired("organization_id")))
+		return
+	}
+
+	polygon, apiErr := parseBoundary(req.Boundary)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	org, apiErr := checkAreaOwner(database.WithContext(c.Request.Context()), role, currentOrgUUID, req.OrganizationID)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	var parentAreaID *uuid.UUID
+	if org.Type == models.OrgTypeContractor {
+		parent, apiErr := findParentArea(database.WithContext(c.Request.Context()), *org.ParentOrgID, polygon)
+		if apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+		parentAreaID = &parent.ID
+	}
+
+	box := polygon.BBox()
+	area := models.ServiceArea{
+		OrganizationID: org.ID,
+		ParentAreaID:   parentAreaID,
+		Name:           req.Name,
+		District:       req.District,
+		Boundary:       string(req.Boundary),
+		MinLon:         box.MinLon,
+		MinLat:         box.MinLat,
+		MaxLon:         box.MaxLon,
+		MaxLat:         box.MaxLat,
+		IsActive:       true,
+	}
+
+	if err := database.WithContext(c.Request.Context()).Create(&area).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create service area", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"service_area": toServiceAreaDTO(area)})
+}
+
+func GetServiceArea(c *gin.Context) {
+	area, ok := loadAccessibleArea(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_area": toServiceAreaDTO(*area)})
+}
+
+func UpdateServiceArea(c *gin.Context) {
+	area, ok := loadAccessibleArea(c, true)
+	if !ok {
+		return
+	}
+
+	var req ServiceAreaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	polygon, apiErr := parseBoundary(req.Boundary)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	parentAreaID := area.ParentAreaID
+	if area.ParentAreaID != nil {
+		// Участок подрядчика должен остаться внутри участков его ТОО.
+		var org models.Organization
+		if err := database.WithContext(c.Request.Context()).Where("id = ?", area.OrganizationID).First(&org).Error; err != nil {
+			apierror.Respond(c, apierror.Internal("failed to fetch organization", err))
+			return
+		}
+		if org.ParentOrgID == nil {
+			apierror.Respond(c, apierror.New(apierror.CodeAreaOutsideParent))
+			return
+		}
+		parent, apiErr := findParentArea(database.WithContext(c.Request.Context()), *org.ParentOrgID, polygon)
+		if apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+		parentAreaID = &parent.ID
+	} else if apiErr := checkChildAreasInside(database.WithContext(c.Request.Context()), area.OrganizationID, area.ID, &polygon); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	box := polygon.BBox()
+	if err := database.WithContext(c.Request.Context()).Model(area).Updates(map[string]interface{}{
+		"name":           req.Name,
+		"district":       req.District,
+		"boundary":       string(req.Boundary),
+		"parent_area_id": parentAreaID,
+		"min_lon":        box.MinLon,
+		"min_lat":        box.MinLat,
+		"max_lon":        box.MaxLon,
+		"max_lat":        box.MaxLat,
+	}).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to update service area", err))
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", area.ID).First(area).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch service area", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_area": toServiceAreaDTO(*area)})
+}
+
+func DeleteServiceArea(c *gin.Context) {
+	area, ok := loadAccessibleArea(c, true)
+	if !ok {
+		return
+	}
+
+	var children int64
+	if err := database.WithContext(c.Request.Context()).Model(&models.ServiceArea{}).
+		Where("parent_area_id = ? AND is_active = ?", area.ID, true).
+		Count(&children).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch service areas", err))
+		return
+	}
+	if children > 0 {
+		apierror.Respond(c, apierror.New(apierror.CodeAreaHasChildren))
+		return
+	}
+	if area.ParentAreaID == nil {
+		// Участки подрядчиков могут охватывать несколько участков ТОО.
+		if apiErr := checkChildAreasInside(database.WithContext(c.Request.Context()), area.OrganizationID, area.ID, nil); apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(area).Update("is_active", false).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to deactivate service area", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseCoordinate читает координату из строки запроса и проверяет диапазон.
+func parseCoordinate(c *gin.Context, name string, limit float64) (float64, *apierror.Error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, apierror.New(apierror.CodeValidationFailed).WithDetails(apierror.FieldRequired(name))
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < -limit || value > limit {
+		return 0, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: name, Rule: "max", Param: strconv.FormatFloat(limit, 'f', -1, 64)})
+	}
+	return value, nil
+}
+
+// GetAreaCoverage возвращает активные участки, покрывающие точку, и закреплённые за ними
+// организации: сначала подрядчиков, затем ТОО.
+func GetAreaCoverage(c *gin.Context) {
+	role, _, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+	if !models.IsAdmin(role) {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	lat, apiErr := parseCoordinate(c, "lat", 90)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	lon, apiErr := parseCoordinate(c, "lon", 180)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	var candidates []models.ServiceArea
+	if err := database.WithContext(c.Request.Context()).Preload("Organization").
+		Joins("JOIN organizations ON organizations.id = service_areas.organization_id").
+		Where("service_areas.is_active = ? AND organizations.is_active = ?", true, true).
+		Where("service_areas.min_lon <= ? AND service_areas.max_lon >= ? AND service_areas.min_lat <= ? AND service_areas.max_lat >= ?", lon, lon, lat, lat).
+		Order("service_areas.parent_area_id NULLS LAST, service_areas.name").
+		Find(&candidates).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch service areas", err))
+		return
+	}
+
+	point := geo.Point{Lon: lon, Lat: lat}
+	result := make([]AreaCoverageDTO, 0)
+	for _, area := range candidates {
+		polygon, err := areaPolygon(area)
+		if err != nil || !polygon.ContainsPoint(point) || area.Organization == nil {
+			continue
+		}
+		result = append(result, AreaCoverageDTO{
+			Area:         toServiceAreaDTO(area),
+			Organization: toOrganizationDTO(*area.Organization),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lat": lat, "lon": lon, "coverage": result})
+}
@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// Ограничения выборок смен.
+const (
+	maxShiftListPeriod = 31 * 24 * time.Hour
+	upcomingShiftLimit = 50
+)
+
+// ShiftRequest описывает смену. Подрядчик определяется по водителю.
+type ShiftRequest struct {
+	DriverID     uuid.UUID `json:"driver_id" binding:"required"`
+	VehicleID    uuid.UUID `json:"vehicle_id" binding:"required"`
+	PlannedStart time.Time `json:"planned_start" binding:"required"`
+	PlannedEnd   time.Time `json:"planned_end" binding:"required"`
+	Note         string    `json:"note" binding:"max=512"`
+}
+
+// ShiftDTO — представление смены в ответах API.
+type ShiftDTO struct {
+	ID                  uuid.UUID `json:"id"`
+	ContractorID        uuid.UUID `json:"contractor_id"`
+	DriverID            uuid.UUID `json:"driver_id"`
+	DriverFullName      string    `json:"driver_full_name,omitempty"`
+	VehicleID           uuid.UUID `json:"vehicle_id"`
+	VehiclePlateDisplay string    `json:"vehicle_plate_display,omitempty"`
+	PlannedStart        time.Time `json:"planned_start"`
+	PlannedEnd          time.Time `json:"planned_end"`
+	Status              string    `json:"status"`
+	Note                string    `json:"note"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func toShiftDTO(shift models.Shift) ShiftDTO {
+	dto := ShiftDTO{
+		ID:           shift.ID,
+		ContractorID: shift.ContractorID,
+		DriverID:     shift.DriverID,
+		VehicleID:    shift.VehicleID,
+		PlannedStart: shift.PlannedStart,
+		PlannedEnd:   shift.PlannedEnd,
+		Status:       shift.Status,
+		Note:         shift.Note,
+		CreatedAt:    shift.CreatedAt,
+		UpdatedAt:    shift.UpdatedAt,
+	}
+	if shift.Driver != nil {
+		dto.DriverFullName = shift.Driver.FullName
+	}
+	if shift.Vehicle != nil {
+		dto.VehiclePlateDisplay = shift.Vehicle.PlateDisplay
+	}
+	return dto
+}
+
+func toShiftDTOs(shifts []models.Shift) []ShiftDTO {
+	result := make([]ShiftDTO, 0, len(shifts))
+	for _, shift := range shifts {
+		result = append(result, toShiftDTO(shift))
+	}
+	return result
+}
+
+// validateShiftPeriod проверяет порядок и длительность планового периода смены.
+func validateShiftPeriod(start, end time.Time) *apierror.Error {
+	if !end.After(start) {
+		return apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "planned_end", Rule: "gtfield", Param: "planned_start"})
+	}
+	if end.Sub(start) > models.MaxShiftDuration {
+		return apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "planned_end", Rule: "max_duration", Param: models.MaxShiftDuration.String()})
+	}
+	return nil
+}
+
+// lockShiftResources блокирует строки водителя и техники до конца транзакции, чтобы
+// параллельные запросы не назначили их на пересекающиеся смены, и проверяет,
+// что их можно поставить в смену.
+func lockShiftResources(tx *gorm.DB, driverID, vehicleID uuid.UUID) (*models.Driver, *models.Vehicle, *apierror.Error) {
+	var driver models.Driver
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", driverID).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.New(apierror.CodeDriverNotFound)
+		}
+		return nil, nil, apierror.Internal("failed to fetch driver", err)
+	}
+
+	var vehicle models.Vehicle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", vehicleID).First(&vehicle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.New(apierror.CodeVehicleNotFound)
+		}
+		return nil, nil, apierror.Internal("failed to fetch vehicle", err)
+	}
+
+	if !driver.IsActive || driver.ContractorID == nil {
+		return nil, nil, apierror.New(apierror.CodeShiftNotSchedulable).
+			WithMessage("inactive driver cannot be scheduled").With("driver_id", driver.ID)
+	}
+	if !vehicle.IsActive {
+		return nil, nil, apierror.New(apierror.CodeShiftNotSchedulable).
+			WithMessage("inactive vehicle cannot be scheduled").With("vehicle_id", vehicle.ID)
+	}
+	if vehicle.ContractorID == nil || *vehicle.ContractorID != *driver.ContractorID {
+		return nil, nil, apierror.New(apierror.CodeShiftNotSchedulable).
+			WithMessage("vehicle does not belong to the driver's contractor")
+	}
+
+	return &driver, &vehicle, nil
+}
+
+// findShiftConflict ищет запланированную смену водителя или техники, пересекающуюся с периодом.
+func findShiftConflict(tx *gorm.DB, driverID, vehicleID uuid.UUID, start, end time.Time, excludeID *uuid.UUID) *apierror.Error {
+	q := tx.Where("status = ? AND planned_start < ? AND planned_end > ?", models.ShiftStatusPlanned, end, start).
+		Where("driver_id = ? OR vehicle_id = ?", driverID, vehicleID)
+	if excludeID != nil {
+		q = q.Where("id <> ?", *excludeID)
+	}
+
+	var conflict models.Shift
+	if err := q.Order("planned_start").First(&conflict).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return apierror.Internal("failed to check shift conflicts", err)
+	}
+
+	apiErr := apierror.New(apierror.CodeShiftConflict).With("conflicting_shift_id", conflict.ID)
+	if conflict.DriverID == driverID {
+		return apiErr.WithMessage("driver is already booked for this period")
+	}
+	return apiErr.WithMessage("vehicle is already booked for this period")
+}
+
+// saveShift проверяет водителя, технику и пересечения и сохраняет смену в одной транзакции.
+func saveShift(c *gin.Context, shift *models.Shift, create bool) bool {
+	role, currentOrgUUID, _ := requireCurrentOrg(c)
+
+	err := database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		driver, _, apiErr := lockShiftResources(tx, shift.DriverID, shift.VehicleID)
+		if apiErr != nil {
+			return apiErr
+		}
+
+		allowed, err := canAccessContractor(tx, role, currentOrgUUID, driver.ContractorID)
+		if err != nil {
+			return apierror.Internal("failed to check driver access", err)
+		}
+		if !allowed {
+			return apierror.New(apierror.CodeForbiddenScope)
+		}
+
+		var excludeID *uuid.UUID
+		if !create {
+			excludeID = &shift.ID
+		}
+		if apiErr := findShiftConflict(tx, shift.DriverID, shift.VehicleID, shift.PlannedStart, shift.PlannedEnd, excludeID); apiErr != nil {
+			return apiErr
+		}
+
+		shift.ContractorID = *driver.ContractorID
+		if create {
+			return tx.Create(shift).Error
+		}
+		return tx.Save(shift).Error
+	})
+	if err != nil {
+		var apiErr *apierror.Error
+		if errors.As(err, &apiErr) {
+			apierror.Respond(c, apiErr)
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to save shift", err))
+		}
+		return false
+	}
+
+	return true
+}
+
+// loadAccessibleShift загружает смену из параметра :id и проверяет доступ к её подрядчику.
+func loadAccessibleShift(c *gin.Context) (*models.Shift, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	shiftUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid shift id"))
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	var shift models.Shift
+	if err := database.WithContext(c.Request.Context()).Preload("Driver").Preload("Vehicle").Where("id = ?", shiftUUID).First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeShiftNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch shift", err))
+		}
+		return nil, false
+	}
+
+	allowed, err := canAccessContractor(database.WithContext(c.Request.Context()), role, currentOrgUUID, &shift.ContractorID)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check shift access", err))
+		return nil, false
+	}
+	if !allowed {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return nil, false
+	}
+
+	return &shift, true
+}
+
+// parseTimeQuery читает необязательный момент времени в формате RFC 3339.
+func parseTimeQuery(c *gin.Context, name string, fallback time.Time) (time.Time, *apierror.Error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: name, Rule: "datetime", Param: time.RFC3339})
+	}
+	return value, nil
+}
+
+// ListShifts возвращает смены подрядчиков в области видимости за период from–to
+// (по умолчанию — ближайшие сутки).
+func ListShifts(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	requested, apiErr := parseContractorQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	now := time.Now()
+	from, apiErr := parseTimeQuery(c, "from", now)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	to, apiErr := parseTimeQuery(c, "to", from.Add(24*time.Hour))
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+	if !to.After(from) {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "to", Rule: "gtfield", Param: "from"}))
+		return
+	}
+	if to.Sub(from) > maxShiftListPeriod {
+		apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "to", Rule: "max_duration", Param: maxShiftListPeriod.String()}))
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	q := database.WithContext(c.Request.Context()).Where("status = ? AND planned_start < ? AND planned_end > ?", models.ShiftStatusPlanned, to, from)
+	if contractorIDs != nil {
+		q = q.Where("contractor_id IN ?", contractorIDs)
+	}
+	if raw := c.Query("driver_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+			return
+		}
+		q = q.Where("driver_id = ?", id)
+	}
+	if raw := c.Query("vehicle_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid vehicle id"))
+			return
+		}
+		q = q.Where("vehicle_id = ?", id)
+	}
+
+	var shifts []models.Shift
+	if err := q.Preload("Driver").Preload("Vehicle").Order("planned_start").Find(&shifts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch shifts", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "shifts": toShiftDTOs(shifts)})
+}
+
+func CreateShift(c *gin.Context) {
+	role, _, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if role != models.RoleContractorAdmin && role != models.RoleTooAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	var req ShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if apiErr := validateShiftPeriod(req.PlannedStart, req.PlannedEnd); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	shift := models.Shift{
+		DriverID:     req.DriverID,
+		VehicleID:    req.VehicleID,
+		PlannedStart: req.PlannedStart,
+		PlannedEnd:   req.PlannedEnd,
+		Status:       models.ShiftStatusPlanned,
+		Note:         req.Note,
+	}
+	if !saveShift(c, &shift, true) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"shift": toShiftDTO(shift)})
+}
+
+func GetShift(c *gin.Context) {
+	shift, ok := loadAccessibleShift(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shift": toShiftDTO(*shift)})
+}
+
+func UpdateShift(c *gin.Context) {
+	shift, ok := loadAccessibleShift(c)
+	if !ok {
+		return
+	}
+
+	role, _, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+	if role != models.RoleContractorAdmin && role != models.RoleTooAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	if shift.Status != models.ShiftStatusPlanned {
+		apierror.Respond(c, apierror.New(apierror.CodeShiftNotSchedulable).WithMessage("cancelled shift cannot be changed"))
+		return
+	}
+
+	var req ShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if apiErr := validateShiftPeriod(req.PlannedStart, req.PlannedEnd); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	shift.DriverID = req.DriverID
+	shift.VehicleID = req.VehicleID
+	shift.PlannedStart = req.PlannedStart
+	shift.PlannedEnd = req.PlannedEnd
+	shift.Note = req.Note
+	shift.Driver = nil
+	shift.Vehicle = nil
+	if !saveShift(c, shift, false) {
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Preload("Driver").Preload("Vehicle").Where("id = ?", shift.ID).First(shift).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch shift", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shift": toShiftDTO(*shift)})
+}
+
+// DeleteShift отменяет смену; запись сохраняется для истории.
+func DeleteShift(c *gin.Context) {
+	shift, ok := loadAccessibleShift(c)
+	if !ok {
+		return
+	}
+
+	role, _, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+	if role != models.RoleContractorAdmin && role != models.RoleTooAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(shift).Update("status", models.ShiftStatusCancelled).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to cancel shift", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListOnDutyShifts возвращает смены, идущие в момент at (по умолчанию — сейчас),
+// у подрядчиков в области видимости ТОО или акимата.
+func ListOnDutyShifts(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	requested, apiErr := parseContractorQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	at, apiErr := parseTimeQuery(c, "at", time.Now())
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	q := database.WithContext(c.Request.Context()).Where("status = ? AND planned_start <= ? AND planned_end > ?", models.ShiftStatusPlanned, at, at)
+	if contractorIDs != nil {
+		q = q.Where("contractor_id IN ?", contractorIDs)
+	}
+
+	var shifts []models.Shift
+	if err := q.Preload("Driver").Preload("Vehicle").Order("contractor_id, planned_start").Find(&shifts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch shifts", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"at": at, "shifts": toShiftDTOs(shifts)})
+}
+
+// ListMyShifts возвращает водителю его текущие и предстоящие смены.
+func ListMyShifts(c *gin.Context) {
+	principal, ok := auth.Require(c)
+	if !ok {
+		return
+	}
+	if principal.Role != models.RoleDriver {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	driverID, ok := currentDriverID(c)
+	if !ok {
+		return
+	}
+
+	var shifts []models.Shift
+	if err := database.WithContext(c.Request.Context()).Preload("Vehicle").
+		Where("driver_id = ? AND status = ? AND planned_end > ?", driverID, models.ShiftStatusPlanned, time.Now()).
+		Order("planned_start").
+		Limit(upcomingShiftLimit).
+		Find(&shifts).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch shifts", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shifts": toShiftDTOs(shifts)})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/MSTimX/Snowops-roles/internal/database"
+)
+
+// stubDB подменяет database.DB заглушкой, которая ожидает запросы в заданном
+// порядке. Ожидаемый запрос — фрагмент SQL: пробелы схлопываются, регистр и
+// кавычки идентификаторов не учитываются.
+func stubDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherFunc(matchSQL)))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("database: %v", err)
+		}
+	})
+	return mock
+}
+
+var sqlSpaces = regexp.MustCompile(`\s+`)
+
+func normalizeSQL(query string) string {
+	query = strings.ReplaceAll(strings.ToLower(query), `"`, "")
+	return strings.TrimSpace(sqlSpaces.ReplaceAllString(query, " "))
+}
+
+func matchSQL(expected, actual string) error {
+	if !strings.Contains(normalizeSQL(actual), normalizeSQL(expected)) {
+		return &sqlMismatch{expected: expected, actual: actual}
+	}
+	return nil
+}
+
+type sqlMismatch struct{ expected, actual string }
+
+func (e *sqlMismatch) Error() string {
+	return "query " + e.actual + " does not contain " + e.expected
+}
+
+// rows строит результат запроса из пар «столбец — значение».
+func rows(pairs ...any) *sqlmock.Rows {
+	columns := make([]string, 0, len(pairs)/2)
+	values := make([]driver.Value, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		columns = append(columns, pairs[i].(string))
+		values = append(values, pairs[i+1])
+	}
+	return sqlmock.NewRows(columns).AddRow(values...)
+}
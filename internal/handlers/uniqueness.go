@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// UniquenessConflict описывает существующую запись, с которой конфликтует
+// создаваемая или изменяемая сущность.
+// ID не заполняется, если запись вне области видимости пользователя.
+type UniquenessConflict struct {
+	Entity   string     `json:"entity"`
+	Field    string     `json:"field"`
+	ID       *uuid.UUID `json:"id,omitempty"`
+	IsActive bool       `json:"is_active"`
+
+	// ownerOrgID — организация, которой принадлежит запись.
+	ownerOrgID *uuid.UUID
+	// rehirable — неактивный водитель, найденный по ИИН из запроса.
+	rehirable bool
+}
+
+// scopeConflict скрывает идентификатор записи, недоступной роли. Неактивный
+// водитель, найденный по ИИН, остаётся видимым: его принимают обратно, подтвердив ИИН.
+func scopeConflict(db *gorm.DB, role string, currentOrgID uuid.UUID, conflict *UniquenessConflict) (*UniquenessConflict, error) {
+	if conflict == nil || conflict.rehirable {
+		return conflict, nil
+	}
+
+	allowed, err := canAccessContractor(db, role, currentOrgID, conflict.ownerOrgID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		scoped := *conflict
+		scoped.ID = nil
+		return &scoped, nil
+	}
+	return conflict, nil
+}
+
+// respondDriverDuplicate отвечает на нарушение уникального индекса при записи
+// водителя теми же сведениями о конфликте, что и предварительная проверка.
+func respondDriverDuplicate(c *gin.Context, db *gorm.DB, role string, currentOrgID uuid.UUID, iin, phone string, excludeID *uuid.UUID) {
+	conflict, err := findDriverConflict(db, iin, phone, excludeID, false)
+	if err == nil {
+		conflict, err = scopeConflict(db, role, currentOrgID, conflict)
+	}
+	if err != nil || conflict == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity))
+		return
+	}
+	respondConflict(c, conflict)
+}
+
+func respondConflict(c *gin.Context, conflict *UniquenessConflict) {
+	if !conflict.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeDriverRehireRequired).With("conflict", conflict))
+		return
+	}
+
+	apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).
+		WithMessage("%s with this %s already exists", i18n.Label(conflict.Entity), i18n.Label(conflict.Field)).
+		With("conflict", conflict))
+}
+
+// findOrganizationBINConflict ищет активную организацию с тем же БИН.
+func findOrganizationBINConflict(db *gorm.DB, bin string, excludeID *uuid.UUID) (*UniquenessConflict, error) {
+	if bin == "" {
+		return nil, nil
+	}
+
+	q := db.Model(&models.Organization{}).Where("bin = ? AND is_active = ?", bin, true)
+	if excludeID != nil {
+		q = q.Where("id <> ?", *excludeID)
+	}
+
+	var org models.Organization
+	if err := q.First(&org).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &UniquenessConflict{Entity: "organization", Field: "bin", ID: &org.ID, IsActive: true, ownerOrgID: &org.ID}, nil
+}
+
+// findUserPhoneConflict ищет активного пользователя с тем же телефоном.
+func findUserPhoneConflict(db *gorm.DB, phone string, excludeID *uuid.UUID) (*UniquenessConflict, error) {
+	if phone == "" {
+		return nil, nil
+	}
+
+	q := db.Model(&models.User{}).Where("phone = ? AND is_active = ?", phone, true)
+	if excludeID != nil {
+		q = q.Where("id <> ?", *excludeID)
+	}
+
+	var user models.User
+	if err := q.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &UniquenessConflict{Entity: "user", Field: "phone", ID: &user.ID, IsActive: true, ownerOrgID: user.OrganizationID}, nil
+}
+
+// findDriverConflict проверяет ИИН и телефон водителя среди активных водителей
+// и пользователей. Если includeInactive установлен, неактивный водитель с тем же
+// ИИН тоже считается конфликтом, чтобы вместо дубликата его приняли обратно.
+func findDriverConflict(db *gorm.DB, iin, phone string, excludeID *uuid.UUID, includeInactive bool) (*UniquenessConflict, error) {
+	if iin != "" {
+		q := db.Model(&models.Driver{}).Where("iin = ?", iin)
+		if !includeInactive {
+			q = q.Where("is_active = ?", true)
+		}
+		if excludeID != nil {
+			q = q.Where("id <> ?", *excludeID)
+		}
+
+		var driver models.Driver
+		err := q.Order("is_active DESC").Order("updated_at DESC").First(&driver).Error
+		if err == nil {
+			return &UniquenessConflict{Entity: "driver", Field: "iin", ID: &driver.ID, IsActive: driver.IsActive, ownerOrgID: driver.ContractorID, rehirable: !driver.IsActive}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if phone != "" {
+		q := db.Model(&models.Driver{}).Where("phone = ? AND is_active = ?", phone, true)
+		if excludeID != nil {
+			q = q.Where("id <> ?", *excludeID)
+		}
+
+		var driver models.Driver
+		err := q.First(&driver).Error
+		if err == nil {
+			return &UniquenessConflict{Entity: "driver", Field: "phone", ID: &driver.ID, IsActive: true, ownerOrgID: driver.ContractorID}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		userQuery := db.Model(&models.User{}).Where("phone = ? AND is_active = ?", phone, true)
+		if excludeID != nil {
+			userQuery = userQuery.Where("driver_id IS NULL OR driver_id <> ?", *excludeID)
+		}
+
+		var user models.User
+		err = userQuery.First(&user).Error
+		if err == nil {
+			return &UniquenessConflict{Entity: "user", Field: "phone", ID: &user.ID, IsActive: true, ownerOrgID: user.OrganizationID}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// VehicleDocumentRequest описывает документ техники. Срок действия обязателен
+// для техосмотра и полиса ОГПО и не нужен для свидетельства о регистрации.
+type VehicleDocumentRequest struct {
+	Type      string `json:"type" binding:"required,oneof=REGISTRATION_CERTIFICATE TECHNICAL_INSPECTION INSURANCE_OGPO"`
+	Number    string `json:"number" binding:"required"`
+	Issuer    string `json:"issuer"`
+	IssuedAt  string `json:"issued_at" binding:"required,datetime=2006-01-02"`
+	ExpiresAt string `json:"expires_at" binding:"omitempty,datetime=2006-01-02"`
+	FileRef   string `json:"file_ref"`
+}
+
+// VehicleDocumentDTO — представление документа техники в ответах API.
+type VehicleDocumentDTO struct {
+	ID              uuid.UUID `json:"id"`
+	VehicleID       uuid.UUID `json:"vehicle_id"`
+	Type            string    `json:"type"`
+	Number          string    `json:"number"`
+	Issuer          string    `json:"issuer"`
+	IssuedAt        string    `json:"issued_at"`
+	ExpiresAt       *string   `json:"expires_at"`
+	FileRef         string    `json:"file_ref"`
+	IsExpired       bool      `json:"is_expired"`
+	DaysUntilExpiry *int      `json:"days_until_expiry"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ExpiringVehicleDocumentDTO дополняет документ сведениями о технике для отчёта об истечении.
+type ExpiringVehicleDocumentDTO struct {
+	VehicleDocumentDTO
+	PlateNumber  string     `json:"plate_number"`
+	ContractorID *uuid.UUID `json:"contractor_id"`
+}
+
+func toVehicleDocumentDTO(doc models.VehicleDocument, now time.Time) VehicleDocumentDTO {
+	dto := VehicleDocumentDTO{
+		ID:        doc.ID,
+		VehicleID: doc.VehicleID,
+		Type:      doc.Type,
+		Number:    doc.Number,
+		Issuer:    doc.Issuer,
+		IssuedAt:  doc.IssuedAt.Format(dateLayout),
+		FileRef:   doc.FileRef,
+		IsExpired: doc.IsExpired(now),
+		CreatedAt: doc.CreatedAt,
+		UpdatedAt: doc.UpdatedAt,
+	}
+
+	if doc.ExpiresAt != nil {
+		expiresAt := doc.ExpiresAt.Format(dateLayout)
+		days := daysUntil(now, *doc.ExpiresAt)
+		dto.ExpiresAt = &expiresAt
+		dto.DaysUntilExpiry = &days
+	}
+
+	return dto
+}
+
+func validateVehicleDocumentRequest(req VehicleDocumentRequest) (time.Time, *time.Time, *apierror.Error) {
+	if req.ExpiresAt == "" {
+		if models.VehicleDocumentRequiresExpiry(req.Type) {
+			return time.Time{}, nil, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldRequired("expires_at"))
+		}
+
+		issued, err := time.Parse(dateLayout, req.IssuedAt)
+		if err != nil {
+			return time.Time{}, nil, apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "issued_at", Rule: "datetime", Param: dateLayout})
+		}
+		return issued, nil, nil
+	}
+
+	issued, expires, apiErr := parseDocumentDates(req.IssuedAt, req.ExpiresAt)
+	if apiErr != nil {
+		return time.Time{}, nil, apiErr
+	}
+	return issued, &expires, nil
+}
+
+// loadVehicleDocument загружает активный документ из параметра :docId для техники.
+func loadVehicleDocument(c *gin.Context, vehicleID uuid.UUID) (*models.VehicleDocument, bool) {
+	docUUID, err := uuid.Parse(c.Param("docId"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid document id"))
+		return nil, false
+	}
+
+	var doc models.VehicleDocument
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND vehicle_id = ? AND is_active = ?", docUUID, vehicleID, true).First(&doc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeDocumentNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch vehicle document", err))
+		}
+		return nil, false
+	}
+
+	return &doc, true
+}
+
+func ListVehicleDocuments(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	var docs []models.VehicleDocument
+	if err := database.WithContext(c.Request.Context()).Where("vehicle_id = ? AND is_active = ?", vehicle.ID, true).Order("expires_at NULLS FIRST").Find(&docs).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch vehicle documents", err))
+		return
+	}
+
+	now := time.Now()
+	result := make([]VehicleDocumentDTO, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, toVehicleDocumentDTO(doc, now))
+	}
+
+	clearance := models.EvaluateVehicleClearance(*vehicle, docs, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents":        result,
+		"cleared_for_work": clearance.Cleared,
+		"clearance_issues": clearanceIssues(clearance),
+	})
+}
+
+func CreateVehicleDocument(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	var req VehicleDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	issuedAt, expiresAt, apiErr := validateVehicleDocumentRequest(req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	doc := models.VehicleDocument{
+		VehicleID: vehicle.ID,
+		Type:      req.Type,
+		Number:    req.Number,
+		Issuer:    req.Issuer,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+		FileRef:   req.FileRef,
+		IsActive:  true,
+	}
+
+	if err := database.WithContext(c.Request.Context()).Create(&doc).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create vehicle document", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"document": toVehicleDocumentDTO(doc, time.Now())})
+}
+
+func GetVehicleDocument(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadVehicleDocument(c, vehicle.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": toVehicleDocumentDTO(*doc, time.Now())})
+}
+
+func UpdateVehicleDocument(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadVehicleDocument(c, vehicle.ID)
+	if !ok {
+		return
+	}
+
+	var req VehicleDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	issuedAt, expiresAt, apiErr := validateVehicleDocumentRequest(req)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(doc).Updates(map[string]interface{}{
+		"type":       req.Type,
+		"number":     req.Number,
+		"issuer":     req.Issuer,
+		"issued_at":  issuedAt,
+		"expires_at": expiresAt,
+		"file_ref":   req.FileRef,
+	}).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to update vehicle document", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"document": toVehicleDocumentDTO(*doc, time.Now())})
+}
+
+func DeleteVehicleDocument(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadVehicleDocument(c, vehicle.ID)
+	if !ok {
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(doc).Update("is_active", false).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to delete vehicle document", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListExpiringVehicleDocuments возвращает истекающие и (по умолчанию) уже истёкшие
+// документы техники в пределах подрядчиков, доступных текущему пользователю.
+func ListExpiringVehicleDocuments(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	days, requested, includeExpired, apiErr := parseExpiringQuery(c, true)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	now := time.Now()
+	today := models.Today(now)
+	until := today.AddDate(0, 0, days)
+
+	q := database.WithContext(c.Request.Context()).Model(&models.VehicleDocument{}).
+		Joins("JOIN vehicles ON vehicles.id = vehicle_documents.vehicle_id").
+		Where("vehicle_documents.is_active = ? AND vehicles.is_active = ?", true, true).
+		Where("vehicle_documents.expires_at IS NOT NULL AND vehicle_documents.expires_at <= ?", until)
+	if !includeExpired {
+		q = q.Where("vehicle_documents.expires_at >= ?", today)
+	}
+	if contractorIDs != nil {
+		q = q.Where("vehicles.contractor_id IN ?", contractorIDs)
+	}
+
+	var docs []models.VehicleDocument
+	if err := q.Preload("Vehicle").Order("vehicle_documents.expires_at").Find(&docs).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch expiring vehicle documents", err))
+		return
+	}
+
+	result := make([]ExpiringVehicleDocumentDTO, 0, len(docs))
+	for _, doc := range docs {
+		item := ExpiringVehicleDocumentDTO{VehicleDocumentDTO: toVehicleDocumentDTO(doc, now)}
+		if doc.Vehicle != nil {
+			item.PlateNumber = doc.Vehicle.PlateNumber
+			item.ContractorID = doc.Vehicle.ContractorID
+		}
+		result = append(result, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "documents": result})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// VehicleTypeDTO — запись справочника типов техники с локализованным названием.
+type VehicleTypeDTO struct {
+	Code             string `json:"code"`
+	Name             string `json:"name"`
+	HaulsSnow        bool   `json:"hauls_snow"`
+	UsesBodyVolume   bool   `json:"uses_body_volume"`
+	UsesBucketVolume bool   `json:"uses_bucket_volume"`
+	UsesPayload      bool   `json:"uses_payload"`
+	// LicenseCategories — категории удостоверения, с которыми водитель допускается к технике.
+	LicenseCategories []string `json:"license_categories"`
+}
+
+// ContractorCapacityDTO — суммарные возможности активной техники подрядчика.
+type ContractorCapacityDTO struct {
+	ContractorID        uuid.UUID      `json:"contractor_id"`
+	ContractorName      string         `json:"contractor_name"`
+	Vehicles            int64          `json:"vehicles"`
+	HaulingVehicles     int64          `json:"hauling_vehicles"`
+	TotalBodyVolumeM3   float64        `json:"total_body_volume_m3"`
+	TotalBucketVolumeM3 float64        `json:"total_bucket_volume_m3"`
+	TotalPayloadTonnes  float64        `json:"total_payload_tonnes"`
+	ByType              map[string]int `json:"by_type"`
+}
+
+// vehicleTypeCodes — допустимые значения типа для сообщений об ошибках.
+func vehicleTypeCodes() string {
+	codes := make([]string, 0, len(models.VehicleTypes))
+	for _, spec := range models.VehicleTypes {
+		codes = append(codes, spec.Code)
+	}
+	return strings.Join(codes, " ")
+}
+
+// validateVehicleCapacity проверяет, что у техники, вывозящей снег, указан объём кузова.
+func validateVehicleCapacity(vehicleType string, bodyVolumeM3 float64) *apierror.Error {
+	spec, ok := models.LookupVehicleType(vehicleType)
+	if !ok {
+		return apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "type", Rule: "oneof", Param: vehicleTypeCodes()})
+	}
+
+	if spec.HaulsSnow && bodyVolumeM3 <= 0 {
+		return apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "body_volume_m3", Rule: "required_for_type", Param: vehicleType})
+	}
+
+	return nil
+}
+
+// parseVehicleTypeQuery читает необязательный фильтр type из строки запроса.
+func parseVehicleTypeQuery(c *gin.Context) (string, *apierror.Error) {
+	raw := strings.ToUpper(strings.TrimSpace(c.Query("type")))
+	if raw == "" {
+		return "", nil
+	}
+
+	if _, ok := models.LookupVehicleType(raw); !ok {
+		return "", apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "type", Rule: "oneof", Param: vehicleTypeCodes()})
+	}
+	return raw, nil
+}
+
+// ListVehicleTypes возвращает справочник типов техники.
+func ListVehicleTypes(c *gin.Context) {
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+
+	result := make([]VehicleTypeDTO, 0, len(models.VehicleTypes))
+	for _, spec := range models.VehicleTypes {
+		result = append(result, VehicleTypeDTO{
+			Code:              spec.Code,
+			Name:              i18n.LabelFor(lang, "vehicle_type_"+strings.ToLower(spec.Code)),
+			HaulsSnow:         spec.HaulsSnow,
+			UsesBodyVolume:    spec.UsesBodyVolume,
+			UsesBucketVolume:  spec.UsesBucketVolume,
+			UsesPayload:       spec.UsesPayload,
+			LicenseCategories: models.RequiredLicenseCategories(spec.Code),
+		})
+	}
+
+	c.Header("Content-Language", string(lang))
+	c.JSON(http.StatusOK, gin.H{"vehicle_types": result})
+}
+
+// ListVehicleCapacity суммирует характеристики активной техники по подрядчикам
+// в области видимости текущего пользователя. Фильтр type сужает расчёт до одного типа.
+func ListVehicleCapacity(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	requested, apiErr := parseContractorQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	vehicleType, apiErr := parseVehicleTypeQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	type capacityRow struct {
+		ContractorID   uuid.UUID
+		ContractorName string
+		Type           string
+		Vehicles       int64
+		BodyVolumeM3   float64
+		BucketVolumeM3 float64
+		PayloadTonnes  float64
+	}
+
+	q := database.WithContext(c.Request.Context()).Model(&models.Vehicle{}).
+		Select("vehicles.contractor_id, organizations.name AS contractor_name, vehicles.type, "+
+			"COUNT(*) AS vehicles, COALESCE(SUM(vehicles.body_volume_m3), 0) AS body_volume_m3, "+
+			"COALESCE(SUM(vehicles.bucket_volume_m3), 0) AS bucket_volume_m3, "+
+			"COALESCE(SUM(vehicles.payload_tonnes), 0) AS payload_tonnes").
+		Joins("JOIN organizations ON organizations.id = vehicles.contractor_id").
+		Where("vehicles.is_active = ?", true).
+		Group("vehicles.contractor_id, organizations.name, vehicles.type")
+	if contractorIDs != nil {
+		q = q.Where("vehicles.contractor_id IN ?", contractorIDs)
+	}
+	if vehicleType != "" {
+		q = q.Where("vehicles.type = ?", vehicleType)
+	}
+
+	var rows []capacityRow
+	if err := q.Order("organizations.name").Scan(&rows).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to calculate vehicle capacity", err))
+		return
+	}
+
+	result := make([]ContractorCapacityDTO, 0)
+	index := make(map[uuid.UUID]int)
+	for _, row := range rows {
+		i, ok := index[row.ContractorID]
+		if !ok {
+			i = len(result)
+			index[row.ContractorID] = i
+			result = append(result, ContractorCapacityDTO{
+				ContractorID:   row.ContractorID,
+				ContractorName: row.ContractorName,
+				ByType:         map[string]int{},
+			})
+		}
+
+		item := &result[i]
+		item.Vehicles += row.Vehicles
+		item.ByType[row.Type] = int(row.Vehicles)
+		// Объём кузова учитывается только у техники, вывозящей снег.
+		if spec, _ := models.LookupVehicleType(row.Type); spec.HaulsSnow {
+			item.HaulingVehicles += row.Vehicles
+			item.TotalBodyVolumeM3 += row.BodyVolumeM3
+		}
+		item.TotalBucketVolumeM3 += row.BucketVolumeM3
+		item.TotalPayloadTonnes += row.PayloadTonnes
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contractors": result})
+}
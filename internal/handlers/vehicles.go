@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/i18n"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/plate"
+)
+
+type CreateVehicleRequest struct {
+	PlateNumber    string     `json:"plate_number" binding:"required"`
+	Type           string     `json:"type" binding:"omitempty,oneof=DUMP_TRUCK LOADER GRADER SWEEPER TRACTOR"`
+	Brand          string     `json:"brand" binding:"required"`
+	Model          string     `json:"model" binding:"required"`
+	Color          string     `json:"color"`
+	Year           int        `json:"year" binding:"required,min=1950,max=2100"`
+	BodyVolumeM3   float64    `json:"body_volume_m3" binding:"gte=0"`
+	BucketVolumeM3 float64    `json:"bucket_volume_m3" binding:"gte=0"`
+	PayloadTonnes  float64    `json:"payload_tonnes" binding:"gte=0"`
+	DriverID       *uuid.UUID `json:"driver_id"`
+}
+
+// UpdateVehicleRequest содержит изменяемые поля техники. Пустая строка в driver_id
+// снимает назначенного водителя.
+type UpdateVehicleRequest struct {
+	PlateNumber    *string  `json:"plate_number"`
+	Type           *string  `json:"type" binding:"omitempty,oneof=DUMP_TRUCK LOADER GRADER SWEEPER TRACTOR"`
+	Brand          *string  `json:"brand"`
+	Model          *string  `json:"model"`
+	Color          *string  `json:"color"`
+	Year           *int     `json:"year" binding:"omitempty,min=1950,max=2100"`
+	BodyVolumeM3   *float64 `json:"body_volume_m3" binding:"omitempty,gte=0"`
+	BucketVolumeM3 *float64 `json:"bucket_volume_m3" binding:"omitempty,gte=0"`
+	PayloadTonnes  *float64 `json:"payload_tonnes" binding:"omitempty,gte=0"`
+	DriverID       *string  `json:"driver_id"`
+}
+
+// parsePlate разбирает госномер из запроса и возвращает ошибку проверки поля plate_number.
+func parsePlate(raw string) (plate.Plate, *apierror.Error) {
+	parsed, err := plate.Parse(raw)
+	if err != nil {
+		rule := "plate"
+		if errors.Is(err, plate.ErrUnknownRegion) {
+			rule = "plate_region"
+		}
+		return plate.Plate{}, apierror.New(apierror.CodeValidationFailed).
+			WithDetails(apierror.FieldError{Field: "plate_number", Rule: rule})
+	}
+	return parsed, nil
+}
+
+// vehicleClearances загружает документы техники и вычисляет допуск к работе для каждой единицы.
+func vehicleClearances(db *gorm.DB, vehicles []models.Vehicle) (map[uuid.UUID]models.Clearance, error) {
+	result := make(map[uuid.UUID]models.Clearance, len(vehicles))
+	if len(vehicles) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		ids = append(ids, vehicle.ID)
+	}
+
+	var docs []models.VehicleDocument
+	if err := db.Where("vehicle_id IN ? AND is_active = ?", ids, true).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+
+	byVehicle := make(map[uuid.UUID][]models.VehicleDocument, len(vehicles))
+	for _, doc := range docs {
+		byVehicle[doc.VehicleID] = append(byVehicle[doc.VehicleID], doc)
+	}
+
+	now := time.Now()
+	for _, vehicle := range vehicles {
+		result[vehicle.ID] = models.EvaluateVehicleClearance(vehicle, byVehicle[vehicle.ID], now)
+	}
+
+	return result, nil
+}
+
+// respondVehicle отправляет технику вместе с вычисленным допуском к работе.
+func respondVehicle(c *gin.Context, status int, vehicle models.Vehicle) {
+	clearances, err := vehicleClearances(database.WithContext(c.Request.Context()), []models.Vehicle{vehicle})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to evaluate vehicle clearance", err))
+		return
+	}
+
+	c.JSON(status, gin.H{"vehicle": toVehicleDTO(vehicle, clearances[vehicle.ID])})
+}
+
+// loadAccessibleVehicle загружает технику из параметра :id и проверяет доступ к ней.
+func loadAccessibleVehicle(c *gin.Context) (*models.Vehicle, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return nil, false
+	}
+
+	vehicleUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid vehicle id"))
+		return nil, false
+	}
+
+	var vehicle models.Vehicle
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", vehicleUUID).First(&vehicle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeVehicleNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch vehicle", err))
+		}
+		return nil, false
+	}
+
+	allowed, err := canAccessContractor(database.WithContext(c.Request.Context()), role, currentOrgUUID, vehicle.ContractorID)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to check vehicle access", err))
+		return nil, false
+	}
+	if !allowed {
+		apierror.Respond(c, apierror.New(apierror.CodeForbiddenScope))
+		return nil, false
+	}
+
+	return &vehicle, true
+}
+
+// checkVehicleDriver проверяет, что назначаемый водитель активен и работает у того же подрядчика.
+func checkVehicleDriver(db *gorm.DB, driverID uuid.UUID, contractorID *uuid.UUID) *apierror.Error {
+	var driver models.Driver
+	if err := db.Where("id = ? AND is_active = ?", driverID, true).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.New(apierror.CodeDriverNotFound)
+		}
+		return apierror.Internal("failed to fetch driver", err)
+	}
+
+	if contractorID == nil || driver.ContractorID == nil || *driver.ContractorID != *contractorID {
+		return apierror.New(apierror.CodeDriverNotInContractor)
+	}
+
+	return nil
+}
+
+func ListVehicles(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	requested, apiErr := parseContractorQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	vehicleType, apiErr := parseVehicleTypeQuery(c)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorIDs, apiErr := contractorScope(database.WithContext(c.Request.Context()), role, currentOrgUUID, requested)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	q := database.WithContext(c.Request.Context()).Where("is_active = ?", true)
+	if contractorIDs != nil {
+		q = q.Where("contractor_id IN ?", contractorIDs)
+	}
+	if vehicleType != "" {
+		q = q.Where("type = ?", vehicleType)
+	}
+	if raw := c.Query("plate"); raw != "" {
+		// Поиск работает при любом регистре, пробелах и кириллической раскладке.
+		q = q.Where("plate_number = ?", plate.Normalize(raw))
+	}
+
+	var vehicles []models.Vehicle
+	if err := q.Order("plate_number").Find(&vehicles).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch vehicles", err))
+		return
+	}
+
+	clearances, err := vehicleClearances(database.WithContext(c.Request.Context()), vehicles)
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to evaluate vehicle clearance", err))
+		return
+	}
+
+	result := make([]VehicleDTO, 0, len(vehicles))
+	for _, vehicle := range vehicles {
+		result = append(result, toVehicleDTO(vehicle, clearances[vehicle.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vehicles": result})
+}
+
+func CreateVehicle(c *gin.Context) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return
+	}
+
+	if role != models.RoleContractorAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return
+	}
+
+	var req CreateVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	parsedPlate, apiErr := parsePlate(req.PlateNumber)
+	if apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = models.VehicleTypeDumpTruck
+	}
+	if apiErr := validateVehicleCapacity(req.Type, req.BodyVolumeM3); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return
+	}
+
+	contractorID := currentOrgUUID
+	if apiErr := checkContractVehicleLimit(database.WithContext(c.Request.Context()), contractorID); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	if req.DriverID != nil {
+		if apiErr := checkVehicleDriver(database.WithContext(c.Request.Context()), *req.DriverID, &contractorID); apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+	}
+
+	vehicle := models.Vehicle{
+		ContractorID:   &contractorID,
+		PlateNumber:    parsedPlate.Canonical,
+		PlateDisplay:   parsedPlate.Display,
+		Type:           req.Type,
+		Brand:          req.Brand,
+		Model:          req.Model,
+		Color:          req.Color,
+		Year:           req.Year,
+		BodyVolumeM3:   req.BodyVolumeM3,
+		BucketVolumeM3: req.BucketVolumeM3,
+		PayloadTonnes:  req.PayloadTonnes,
+		DriverID:       req.DriverID,
+		IsActive:       true,
+	}
+
+	err := database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vehicle).Error; err != nil {
+			return err
+		}
+		if err := events.RecordVehicle(tx, events.VehicleCreated, vehicle); err != nil {
+			return err
+		}
+		return events.RecordVehicleAssignment(tx, vehicle, nil)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).
+				WithMessage("%s with this %s already exists", i18n.Label("vehicle"), i18n.Label("plate_number")))
+			return
+		}
+		apierror.Respond(c, apierror.Internal("failed to create vehicle", err))
+		return
+	}
+
+	respondVehicle(c, http.StatusCreated, vehicle)
+}
+
+func GetVehicle(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	respondVehicle(c, http.StatusOK, *vehicle)
+}
+
+func UpdateVehicle(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	if !vehicle.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeVehicleNotFound))
+		return
+	}
+
+	var req UpdateVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.PlateNumber != nil {
+		parsedPlate, apiErr := parsePlate(*req.PlateNumber)
+		if apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+		updates["plate_number"] = parsedPlate.Canonical
+		updates["plate_display"] = parsedPlate.Display
+	}
+	if req.Brand != nil {
+		updates["brand"] = *req.Brand
+	}
+	if req.Model != nil {
+		updates["model"] = *req.Model
+	}
+	if req.Color != nil {
+		updates["color"] = *req.Color
+	}
+	if req.Year != nil {
+		updates["year"] = *req.Year
+	}
+	if req.Type != nil || req.BodyVolumeM3 != nil {
+		// Проверяется итоговое сочетание типа и объёма кузова после изменения.
+		vehicleType, bodyVolume := vehicle.Type, vehicle.BodyVolumeM3
+		if req.Type != nil {
+			vehicleType = *req.Type
+			updates["type"] = vehicleType
+		}
+		if req.BodyVolumeM3 != nil {
+			bodyVolume = *req.BodyVolumeM3
+			updates["body_volume_m3"] = bodyVolume
+		}
+		if apiErr := validateVehicleCapacity(vehicleType, bodyVolume); apiErr != nil {
+			apierror.Respond(c, apiErr)
+			return
+		}
+	}
+	if req.BucketVolumeM3 != nil {
+		updates["bucket_volume_m3"] = *req.BucketVolumeM3
+	}
+	if req.PayloadTonnes != nil {
+		updates["payload_tonnes"] = *req.PayloadTonnes
+	}
+	if req.DriverID != nil {
+		if *req.DriverID == "" {
+			updates["driver_id"] = nil
+		} else {
+			driverID, err := uuid.Parse(*req.DriverID)
+			if err != nil {
+				apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid driver id"))
+				return
+			}
+			if apiErr := checkVehicleDriver(database.WithContext(c.Request.Context()), driverID, vehicle.ContractorID); apiErr != nil {
+				apierror.Respond(c, apiErr)
+				return
+			}
+			updates["driver_id"] = driverID
+		}
+	}
+
+	if len(updates) > 0 {
+		previousDriverID := vehicle.DriverID
+		err := database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(vehicle).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id = ?", vehicle.ID).First(vehicle).Error; err != nil {
+				return err
+			}
+			if err := events.RecordVehicle(tx, events.VehicleUpdated, *vehicle); err != nil {
+				return err
+			}
+			return events.RecordVehicleAssignment(tx, *vehicle, previousDriverID)
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				apierror.Respond(c, apierror.New(apierror.CodeDuplicateEntity).
+					WithMessage("%s with this %s already exists", i18n.Label("vehicle"), i18n.Label("plate_number")))
+				return
+			}
+			apierror.Respond(c, apierror.Internal("failed to update vehicle", err))
+			return
+		}
+	}
+
+	respondVehicle(c, http.StatusOK, *vehicle)
+}
+
+func DeleteVehicle(c *gin.Context) {
+	vehicle, ok := loadAccessibleVehicle(c)
+	if !ok {
+		return
+	}
+
+	previousDriverID := vehicle.DriverID
+	err := database.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(vehicle).Updates(map[string]interface{}{
+			"is_active": false,
+			"driver_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+		vehicle.IsActive, vehicle.DriverID = false, nil
+		if err := events.RecordVehicleAssignment(tx, *vehicle, previousDriverID); err != nil {
+			return err
+		}
+		return events.RecordVehicle(tx, events.VehicleDeactivated, *vehicle)
+	})
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to deactivate vehicle", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/database"
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+	"github.com/MSTimX/Snowops-roles/internal/webhooks"
+)
+
+// webhookDeliveryLimit ограничивает размер журнала доставок в одном ответе.
+const webhookDeliveryLimit = 100
+
+// WebhookSubscriptionRequest описывает подписку на события. Пустой event_types —
+// все события; organization_id ограничивает события поддеревом организации.
+type WebhookSubscriptionRequest struct {
+	Name           string     `json:"name" binding:"required,max=255"`
+	URL            string     `json:"url" binding:"required,http_url,max=2048"`
+	EventTypes     []string   `json:"event_types"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	IsActive       *bool      `json:"is_active"`
+}
+
+// WebhookSubscriptionDTO — подписка в ответах API. Secret заполняется только
+// при создании и ротации ключа.
+type WebhookSubscriptionDTO struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	URL            string     `json:"url"`
+	EventTypes     []string   `json:"event_types"`
+	OrganizationID *uuid.UUID `json:"organization_id"`
+	IsActive       bool       `json:"is_active"`
+	Secret         string     `json:"secret,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookDeliveryDTO — запись журнала доставок.
+type WebhookDeliveryDTO struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	ReplayOfID     *uuid.UUID      `json:"replay_of_id"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func toWebhookSubscriptionDTO(subscription models.WebhookSubscription) WebhookSubscriptionDTO {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return WebhookSubscriptionDTO{
+		ID:             subscription.ID,
+		Name:           subscription.Name,
+		URL:            subscription.URL,
+		EventTypes:     eventTypes,
+		OrganizationID: subscription.OrganizationID,
+		IsActive:       subscription.IsActive,
+		CreatedAt:      subscription.CreatedAt,
+		UpdatedAt:      subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryDTO(delivery models.WebhookDelivery) WebhookDeliveryDTO {
+	dto := WebhookDeliveryDTO{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		ReplayOfID:     delivery.ReplayOfID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		Payload:        json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		next := delivery.NextAttemptAt
+		dto.NextAttemptAt = &next
+	}
+	return dto
+}
+
+// requireAkimatAdmin пропускает только администратора акимата.
+func requireAkimatAdmin(c *gin.Context) (uuid.UUID, bool) {
+	role, currentOrgUUID, ok := requireCurrentOrg(c)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if role != models.RoleAkimatAdmin {
+		apierror.Respond(c, apierror.New(apierror.CodeForbidden))
+		return uuid.Nil, false
+	}
+
+	if database.DB == nil {
+		apierror.Respond(c, apierror.New(apierror.CodeDatabaseNotReady))
+		return uuid.Nil, false
+	}
+
+	return currentOrgUUID, true
+}
+
+// validateWebhookRequest проверяет типы событий и организацию фильтра.
+func validateWebhookRequest(db *gorm.DB, req *WebhookSubscriptionRequest) *apierror.Error {
+	for _, eventType := range req.EventTypes {
+		if !events.IsType(eventType) {
+			return apierror.New(apierror.CodeValidationFailed).
+				WithDetails(apierror.FieldError{Field: "event_types", Rule: "oneof", Param: strings.Join(events.Types, " ")})
+		}
+	}
+
+	if req.OrganizationID != nil {
+		var count int64
+		if err := db.Model(&models.Organization{}).
+			Where("id = ? AND is_active = ?", *req.OrganizationID, true).
+			Count(&count).Error; err != nil {
+			return apierror.Internal("failed to fetch organization", err)
+		}
+		if count == 0 {
+			return apierror.New(apierror.CodeOrgNotFound)
+		}
+	}
+
+	return nil
+}
+
+// loadWebhookSubscription загружает подписку из параметра :id.
+func loadWebhookSubscription(c *gin.Context) (*models.WebhookSubscription, bool) {
+	if _, ok := requireAkimatAdmin(c); !ok {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid webhook id"))
+		return nil, false
+	}
+
+	var subscription models.WebhookSubscription
+	if err := database.WithContext(c.Request.Context()).Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeWebhookNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch webhook", err))
+		}
+		return nil, false
+	}
+
+	return &subscription, true
+}
+
+func ListWebhooks(c *gin.Context) {
+	if _, ok := requireAkimatAdmin(c); !ok {
+		return
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := database.WithContext(c.Request.Context()).Order("created_at").Find(&subscriptions).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch webhooks", err))
+		return
+	}
+
+	result := make([]WebhookSubscriptionDTO, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, toWebhookSubscriptionDTO(subscription))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": result})
+}
+
+func CreateWebhook(c *gin.Context) {
+	if _, ok := requireAkimatAdmin(c); !ok {
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if apiErr := validateWebhookRequest(database.WithContext(c.Request.Context()), &req); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to generate webhook secret", err))
+		return
+	}
+
+	subscription := models.WebhookSubscription{
+		Name:           req.Name,
+		URL:            req.URL,
+		EventTypes:     req.EventTypes,
+		OrganizationID: req.OrganizationID,
+		Secret:         secret,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+	}
+	if principal, ok := auth.FromGin(c); ok {
+		subscription.CreatedByID = &principal.UserID
+	}
+
+	if err := database.WithContext(c.Request.Context()).Create(&subscription).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to create webhook", err))
+		return
+	}
+
+	dto := toWebhookSubscriptionDTO(subscription)
+	dto.Secret = subscription.Secret
+	c.JSON(http.StatusCreated, gin.H{"webhook": dto})
+}
+
+func GetWebhook(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": toWebhookSubscriptionDTO(*subscription)})
+}
+
+func UpdateWebhook(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, apierror.FromBinding(err))
+		return
+	}
+
+	if apiErr := validateWebhookRequest(database.WithContext(c.Request.Context()), &req); apiErr != nil {
+		apierror.Respond(c, apiErr)
+		return
+	}
+
+	subscription.Name = req.Name
+	subscription.URL = req.URL
+	subscription.EventTypes = req.EventTypes
+	subscription.OrganizationID = req.OrganizationID
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(subscription).
+		Select("name", "url", "event_types", "organization_id", "is_active").
+		Updates(subscription).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to update webhook", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": toWebhookSubscriptionDTO(*subscription)})
+}
+
+// DeleteWebhook отключает подписку; журнал доставок сохраняется.
+func DeleteWebhook(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(subscription).Update("is_active", false).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to deactivate webhook", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RotateWebhookSecret выдаёт новый ключ подписи; прежний перестаёт действовать сразу.
+func RotateWebhookSecret(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		apierror.Respond(c, apierror.Internal("failed to generate webhook secret", err))
+		return
+	}
+
+	if err := database.WithContext(c.Request.Context()).Model(subscription).Update("secret", secret).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to rotate webhook secret", err))
+		return
+	}
+
+	dto := toWebhookSubscriptionDTO(*subscription)
+	dto.Secret = secret
+	c.JSON(http.StatusOK, gin.H{"webhook": dto})
+}
+
+// ListWebhookDeliveries возвращает последние доставки подписки, при необходимости
+// отфильтрованные по статусу.
+func ListWebhookDeliveries(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	q := database.WithContext(c.Request.Context()).Where("subscription_id = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+			q = q.Where("status = ?", status)
+		default:
+			apierror.Respond(c, apierror.New(apierror.CodeValidationFailed).WithDetails(apierror.FieldError{
+				Field: "status",
+				Rule:  "oneof",
+				Param: strings.Join([]string{models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed}, " "),
+			}))
+			return
+		}
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := q.Order("created_at DESC").Limit(webhookDeliveryLimit).Find(&deliveries).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to fetch webhook deliveries", err))
+		return
+	}
+
+	result := make([]WebhookDeliveryDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, toWebhookDeliveryDTO(delivery))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": result})
+}
+
+// ReplayWebhookDelivery ставит событие доставки в очередь повторно как новую доставку.
+func ReplayWebhookDelivery(c *gin.Context) {
+	subscription, ok := loadWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidID).WithMessage("invalid delivery id"))
+		return
+	}
+
+	var original models.WebhookDelivery
+	if err := database.WithContext(c.Request.Context()).Where("id = ? AND subscription_id = ?", deliveryID, subscription.ID).First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Respond(c, apierror.New(apierror.CodeWebhookDeliveryNotFound))
+		} else {
+			apierror.Respond(c, apierror.Internal("failed to fetch webhook delivery", err))
+		}
+		return
+	}
+
+	if !subscription.IsActive {
+		apierror.Respond(c, apierror.New(apierror.CodeWebhookNotFound).WithMessage("webhook is inactive"))
+		return
+	}
+
+	replayOf := original.ID
+	if original.ReplayOfID != nil {
+		replayOf = *original.ReplayOfID
+	}
+
+	replay := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		ReplayOfID:     &replayOf,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := database.WithContext(c.Request.Context()).Create(&replay).Error; err != nil {
+		apierror.Respond(c, apierror.Internal("failed to replay webhook delivery", err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": toWebhookDeliveryDTO(replay)})
+}
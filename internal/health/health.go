@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkTimeout ограничивает время одной проверки готовности.
+const checkTimeout = 2 * time.Second
+
+// Check — проверка одной зависимости; nil означает, что зависимость готова.
+type Check func(ctx context.Context) error
+
+// Checker выполняет проверки готовности сервиса.
+type Checker struct {
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// NewChecker создаёт пустой набор проверок.
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add регистрирует проверку под именем, которое попадёт в ответ /readyz.
+func (h *Checker) Add(name string, check Check) {
+	h.names = append(h.names, name)
+	h.checks[name] = check
+}
+
+// SetDraining переводит сервис в режим остановки: /readyz сразу отвечает 503,
+// чтобы балансировщик перестал направлять новые запросы.
+func (h *Checker) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Livez сообщает, что процесс жив и обрабатывает запросы. Зависимости не
+// проверяются: их недоступность не должна приводить к перезапуску пода.
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz выполняет все проверки параллельно и отвечает 503, если хотя бы одна
+// не прошла. Текст ошибок отдаётся в ответе: маршрут служебный и не содержит
+// пользовательских данных.
+func (h *Checker) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(h.names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := "ok"
+			if err := check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+
+	status, body := http.StatusOK, "ok"
+	for _, result := range results {
+		if result != "ok" {
+			status, body = http.StatusServiceUnavailable, "unavailable"
+			break
+		}
+	}
+	c.JSON(status, gin.H{"status": body, "checks": results})
+}
@@ -0,0 +1,403 @@
+package i18n
+
+// catalogs содержит переводы сообщений API. Английский текст служит ключом,
+// поэтому английский каталог хранит только расхождения с ключом.
+var catalogs = map[Lang]map[string]string{
+	LangEN: {},
+	LangRU: {
+		"unauthorized":  "требуется аутентификация",
+		"missing token": "отсутствует токен доступа",
+		"invalid token": "недействительный токен доступа",
+		"forbidden":     "доступ запрещён",
+		"resource is outside of your organization scope":        "ресурс не относится к вашей организации",
+		"request validation failed":                             "ошибка проверки запроса",
+		"request body is malformed":                             "некорректное тело запроса",
+		"invalid identifier":                                    "некорректный идентификатор",
+		"invalid organization id":                               "некорректный идентификатор организации",
+		"invalid driver id":                                     "некорректный идентификатор водителя",
+		"invalid current organization id":                       "некорректный идентификатор текущей организации",
+		"unsupported organization type":                         "неподдерживаемый тип организации",
+		"organization not found":                                "организация не найдена",
+		"user not found":                                        "пользователь не найден",
+		"driver not found":                                      "водитель не найден",
+		"entity with the same unique attributes already exists": "запись с такими уникальными данными уже существует",
+		"%s with this %s already exists":                        "%s с таким значением поля «%s» уже существует",
+		"driver with this iin is inactive, rehire it instead":   "водитель с таким ИИН неактивен, примите его повторно",
+		"driver is already active":                              "водитель уже активен",
+		"not implemented yet":                                   "функция ещё не реализована",
+		"internal server error":                                 "внутренняя ошибка сервера",
+		"database not initialized":                              "база данных недоступна",
+		"authentication is not configured":                      "аутентификация не настроена",
+		"required query parameter is missing":                   "не указан обязательный параметр запроса",
+		"phone or login required":                               "укажите телефон или логин",
+		"document not found":                                    "документ не найден",
+		"invalid document id":                                   "некорректный идентификатор документа",
+		"vehicle not found":                                     "техника не найдена",
+		"invalid vehicle id":                                    "некорректный идентификатор техники",
+		"driver does not belong to the vehicle's contractor":    "водитель не относится к подрядчику, которому принадлежит техника",
+
+		"%s is required":                            "поле «%s» обязательно",
+		"%s must be one of: %s":                     "поле «%s» должно принимать одно из значений: %s",
+		"%s must be at least %s":                    "поле «%s» должно быть не меньше %s",
+		"%s must be at most %s":                     "поле «%s» должно быть не больше %s",
+		"%s is invalid":                             "поле «%s» заполнено некорректно",
+		"%s must be a date in %s format":            "поле «%s» должно содержать дату в формате %s",
+		"%s must be after %s":                       "поле «%s» должно быть позже поля «%s»",
+		"%s is not a valid Kazakhstan plate number": "поле «%s» не является госномером Казахстана",
+		"%s has an unknown region code":             "в поле «%s» указан неизвестный код региона",
+		"%s is required for vehicle type %s":        "поле «%s» обязательно для техники типа %s",
+
+		"contract not found":                                                 "договор не найден",
+		"invalid contract id":                                                "некорректный идентификатор договора",
+		"organization has no contract in force":                              "у организации нет действующего договора",
+		"contract vehicle limit reached":                                     "достигнуто допустимое договором количество техники",
+		"contract can only be signed with a TOO or its own contractor":       "договор можно заключить только с ТОО или собственным подрядчиком",
+		"service area not found":                                             "участок не найден",
+		"invalid service area id":                                            "некорректный идентификатор участка",
+		"contractor service area must lie inside the parent TOO's areas":     "участок подрядчика должен находиться внутри участков родительского ТОО",
+		"contractor areas would fall outside the TOO's areas":                "участки подрядчиков окажутся за границами участков ТОО",
+		"service area has active contractor areas":                           "у участка есть активные участки подрядчиков",
+		"service areas can only be assigned to a TOO or your own contractor": "участок можно закрепить только за ТОО или собственным подрядчиком",
+		"%s must be a valid GeoJSON polygon":                                 "поле «%s» должно содержать корректный полигон GeoJSON",
+		"shift not found":                                                    "смена не найдена",
+		"invalid shift id":                                                   "некорректный идентификатор смены",
+		"driver or vehicle is already booked for this period":                "водитель или техника уже заняты в этот период",
+		"driver is already booked for this period":                           "водитель уже назначен на смену в этот период",
+		"vehicle is already booked for this period":                          "техника уже назначена на смену в этот период",
+		"driver or vehicle cannot be scheduled":                              "водителя или технику нельзя назначить на смену",
+		"inactive driver cannot be scheduled":                                "неактивного водителя нельзя назначить на смену",
+		"inactive vehicle cannot be scheduled":                               "неактивную технику нельзя назначить на смену",
+		"vehicle does not belong to the driver's contractor":                 "техника не относится к подрядчику водителя",
+		"cancelled shift cannot be changed":                                  "отменённую смену нельзя изменить",
+		"%s must be at most %s long":                                         "длительность поля «%s» не должна превышать %s",
+		"driver profile is not linked to the current user":                   "профиль водителя не привязан к текущему пользователю",
+		"no pending phone verification":                                      "нет ожидающего подтверждения номера телефона",
+		"verification code is invalid or expired":                            "код подтверждения неверен или устарел",
+		"too many verification attempts, request a new code":                 "слишком много попыток, запросите новый код",
+		"phone number is unchanged":                                          "номер телефона не изменился",
+		"pass cannot be issued for this driver and vehicle":                  "пропуск не может быть выдан этому водителю и технике",
+		"inactive driver cannot receive a pass":                              "неактивному водителю нельзя выдать пропуск",
+		"inactive vehicle cannot receive a pass":                             "на неактивную технику нельзя выдать пропуск",
+		"driver is not cleared for work":                                     "водитель не допущен к работе",
+		"vehicle is not cleared for work":                                    "техника не допущена к работе",
+		"vehicle is not assigned to the driver":                              "техника не закреплена за водителем",
+		"webhook not found":                                                  "подписка webhook не найдена",
+		"webhook delivery not found":                                         "доставка webhook не найдена",
+		"invalid webhook id":                                                 "некорректный идентификатор подписки webhook",
+		"invalid delivery id":                                                "некорректный идентификатор доставки",
+		"webhook is inactive":                                                "подписка webhook отключена",
+		"user account or organization is disabled":                           "учётная запись пользователя или организация отключена",
+		"service account not found":                                          "служебная учётная запись не найдена",
+		"API key not found":                                                  "API-ключ не найден",
+		"invalid service account id":                                         "некорректный идентификатор служебной учётной записи",
+		"invalid API key id":                                                 "некорректный идентификатор API-ключа",
+		"service account is inactive":                                        "служебная учётная запись отключена",
+		"API key is revoked or expired":                                      "API-ключ отозван или истёк",
+		"SMS delivery is not configured":                                     "Отправка SMS не настроена",
+	},
+	LangKK: {
+		"unauthorized":  "аутентификация қажет",
+		"missing token": "қолжетімділік токені жоқ",
+		"invalid token": "қолжетімділік токені жарамсыз",
+		"forbidden":     "қолжетімділік жабық",
+		"resource is outside of your organization scope":        "ресурс сіздің ұйымыңызға қатысты емес",
+		"request validation failed":                             "сұрауды тексеру сәтсіз аяқталды",
+		"request body is malformed":                             "сұрау денесі қате",
+		"invalid identifier":                                    "идентификатор қате",
+		"invalid organization id":                               "ұйым идентификаторы қате",
+		"invalid driver id":                                     "жүргізуші идентификаторы қате",
+		"invalid current organization id":                       "ағымдағы ұйым идентификаторы қате",
+		"unsupported organization type":                         "ұйым түрі қолдау көрсетілмейді",
+		"organization not found":                                "ұйым табылмады",
+		"user not found":                                        "пайдаланушы табылмады",
+		"driver not found":                                      "жүргізуші табылмады",
+		"entity with the same unique attributes already exists": "осындай бірегей деректері бар жазба бұрыннан бар",
+		"%s with this %s already exists":                        "«%[2]s» өрісі осындай %[1]s бұрыннан бар",
+		"driver with this iin is inactive, rehire it instead":   "осы ЖСН-і бар жүргізуші белсенді емес, оны қайта жұмысқа алыңыз",
+		"driver is already active":                              "жүргізуші белсенді",
+		"not implemented yet":                                   "функция әлі іске асырылмаған",
+		"internal server error":                                 "сервердің ішкі қатесі",
+		"database not initialized":                              "дерекқор қолжетімсіз",
+		"authentication is not configured":                      "аутентификация бапталмаған",
+		"required query parameter is missing":                   "міндетті сұрау параметрі көрсетілмеген",
+		"phone or login required":                               "телефонды немесе логинді көрсетіңіз",
+		"document not found":                                    "құжат табылмады",
+		"invalid document id":                                   "құжат идентификаторы қате",
+		"vehicle not found":                                     "техника табылмады",
+		"invalid vehicle id":                                    "техника идентификаторы қате",
+		"driver does not belong to the vehicle's contractor":    "жүргізуші техника тиесілі мердігерге жатпайды",
+
+		"%s is required":                            "«%s» өрісі міндетті",
+		"%s must be one of: %s":                     "«%s» өрісі мына мәндердің бірі болуы керек: %s",
+		"%s must be at least %s":                    "«%s» өрісі %s мәнінен кем болмауы керек",
+		"%s must be at most %s":                     "«%s» өрісі %s мәнінен аспауы керек",
+		"%s is invalid":                             "«%s» өрісі қате толтырылған",
+		"%s must be a date in %s format":            "«%s» өрісі %s пішіміндегі күн болуы керек",
+		"%s must be after %s":                       "«%s» өрісі «%s» өрісінен кейін болуы керек",
+		"%s is not a valid Kazakhstan plate number": "«%s» өрісі Қазақстанның мемлекеттік нөмірі емес",
+		"%s has an unknown region code":             "«%s» өрісінде өңір коды белгісіз",
+		"%s is required for vehicle type %s":        "«%s» өрісі %s түріндегі техника үшін міндетті",
+
+		"contract not found":                                                 "шарт табылмады",
+		"invalid contract id":                                                "шарт идентификаторы қате",
+		"organization has no contract in force":                              "ұйымның қолданыстағы шарты жоқ",
+		"contract vehicle limit reached":                                     "шартта рұқсат етілген техника саны толды",
+		"contract can only be signed with a TOO or its own contractor":       "шартты тек ЖШС-пен немесе өз мердігерімен жасасуға болады",
+		"service area not found":                                             "учаске табылмады",
+		"invalid service area id":                                            "учаске идентификаторы қате",
+		"contractor service area must lie inside the parent TOO's areas":     "мердігердің учаскесі негізгі ЖШС учаскелерінің ішінде болуы тиіс",
+		"contractor areas would fall outside the TOO's areas":                "мердігерлердің учаскелері ЖШС учаскелерінің шегінен шығып кетеді",
+		"service area has active contractor areas":                           "учаскеде мердігерлердің белсенді учаскелері бар",
+		"service areas can only be assigned to a TOO or your own contractor": "учаскені тек ЖШС-ке немесе өз мердігеріңізге бекітуге болады",
+		"%s must be a valid GeoJSON polygon":                                 "«%s» өрісі дұрыс GeoJSON көпбұрышы болуы тиіс",
+		"shift not found":                                                    "ауысым табылмады",
+		"invalid shift id":                                                   "ауысым идентификаторы қате",
+		"driver or vehicle is already booked for this period":                "жүргізуші немесе техника бұл кезеңде бос емес",
+		"driver is already booked for this period":                           "жүргізуші бұл кезеңде ауысымға тағайындалған",
+		"vehicle is already booked for this period":                          "техника бұл кезеңде ауысымға тағайындалған",
+		"driver or vehicle cannot be scheduled":                              "жүргізушіні немесе техниканы ауысымға тағайындауға болмайды",
+		"inactive driver cannot be scheduled":                                "белсенді емес жүргізушіні ауысымға тағайындауға болмайды",
+		"inactive vehicle cannot be scheduled":                               "белсенді емес техниканы ауысымға тағайындауға болмайды",
+		"vehicle does not belong to the driver's contractor":                 "техника жүргізушінің мердігеріне тиесілі емес",
+		"cancelled shift cannot be changed":                                  "бас тартылған ауысымды өзгертуге болмайды",
+		"%s must be at most %s long":                                         "«%s» өрісінің ұзақтығы %s аспауы тиіс",
+		"driver profile is not linked to the current user":                   "жүргізуші профилі ағымдағы пайдаланушыға байланыстырылмаған",
+		"no pending phone verification":                                      "телефон нөмірін растау күтілмейді",
+		"verification code is invalid or expired":                            "растау коды қате немесе мерзімі өткен",
+		"too many verification attempts, request a new code":                 "әрекеттер тым көп, жаңа код сұраңыз",
+		"phone number is unchanged":                                          "телефон нөмірі өзгермеді",
+		"pass cannot be issued for this driver and vehicle":                  "бұл жүргізуші мен техникаға рұқсатнама беру мүмкін емес",
+		"inactive driver cannot receive a pass":                              "белсенді емес жүргізушіге рұқсатнама беруге болмайды",
+		"inactive vehicle cannot receive a pass":                             "белсенді емес техникаға рұқсатнама беруге болмайды",
+		"driver is not cleared for work":                                     "жүргізуші жұмысқа жіберілмеген",
+		"vehicle is not cleared for work":                                    "техника жұмысқа жіберілмеген",
+		"vehicle is not assigned to the driver":                              "техника жүргізушіге бекітілмеген",
+		"webhook not found":                                                  "webhook жазылымы табылмады",
+		"webhook delivery not found":                                         "webhook жеткізілімі табылмады",
+		"invalid webhook id":                                                 "webhook жазылымының идентификаторы қате",
+		"invalid delivery id":                                                "жеткізілім идентификаторы қате",
+		"webhook is inactive":                                                "webhook жазылымы өшірілген",
+		"user account or organization is disabled":                           "пайдаланушының есептік жазбасы немесе ұйым өшірілген",
+		"service account not found":                                          "қызметтік тіркелгі табылмады",
+		"API key not found":                                                  "API кілті табылмады",
+		"invalid service account id":                                         "қызметтік тіркелгінің идентификаторы қате",
+		"invalid API key id":                                                 "API кілтінің идентификаторы қате",
+		"service account is inactive":                                        "қызметтік тіркелгі өшірілген",
+		"API key is revoked or expired":                                      "API кілті кері қайтарылған немесе мерзімі өткен",
+		"SMS delivery is not configured":                                     "SMS жіберу бапталмаған",
+	},
+}
+
+// labels содержит подписи полей запросов и сущностей.
+var labels = map[Lang]map[string]string{
+	LangEN: {
+		"name":                    "name",
+		"type":                    "type",
+		"bin":                     "BIN",
+		"head_full_name":          "head's full name",
+		"address":                 "address",
+		"phone":                   "phone",
+		"admin_full_name":         "administrator's full name",
+		"admin_phone":             "administrator's phone",
+		"admin_password":          "administrator's password",
+		"full_name":               "full name",
+		"iin":                     "IIN",
+		"birth_year":              "year of birth",
+		"organization":            "organization",
+		"user":                    "user",
+		"driver":                  "driver",
+		"number":                  "document number",
+		"category":                "license category",
+		"issued_at":               "issue date",
+		"expires_at":              "expiry date",
+		"file_ref":                "scanned file",
+		"days":                    "number of days",
+		"document":                "document",
+		"vehicle":                 "vehicle",
+		"plate_number":            "plate number",
+		"brand":                   "brand",
+		"model":                   "model",
+		"color":                   "color",
+		"year":                    "year of manufacture",
+		"body_volume_m3":          "body volume, m³",
+		"bucket_volume_m3":        "bucket volume, m³",
+		"payload_tonnes":          "payload, t",
+		"vehicle_type_dump_truck": "Dump truck",
+		"vehicle_type_loader":     "Loader",
+		"vehicle_type_grader":     "Grader",
+		"vehicle_type_sweeper":    "Sweeper",
+		"vehicle_type_tractor":    "Tractor",
+		"driver_id":               "driver",
+		"issuer":                  "issued by",
+		"contract":                "contract",
+		"contractor_org_id":       "contractor organization",
+		"start_date":              "start date",
+		"end_date":                "end date",
+		"districts":               "service districts",
+		"max_vehicles":            "allowed number of vehicles",
+		"status":                  "status",
+		"service_area":            "service area",
+		"organization_id":         "organization",
+		"district":                "district",
+		"boundary":                "boundary",
+		"lat":                     "latitude",
+		"lon":                     "longitude",
+		"shift":                   "shift",
+		"vehicle_id":              "vehicle",
+		"planned_start":           "planned start",
+		"planned_end":             "planned end",
+		"note":                    "note",
+		"at":                      "point in time",
+		"from":                    "period start",
+		"to":                      "period end",
+		"code":                    "verification code",
+		"ttl_minutes":             "pass lifetime in minutes",
+		"token":                   "pass",
+		"url":                     "URL",
+		"event_types":             "event types",
+		"scopes":                  "scopes",
+		"description":             "description",
+		"expires_in_days":         "expiry in days",
+		"grace_period_hours":      "grace period in hours",
+	},
+	LangRU: {
+		"name":                    "Наименование",
+		"type":                    "Тип",
+		"bin":                     "БИН",
+		"head_full_name":          "ФИО руководителя",
+		"address":                 "Адрес",
+		"phone":                   "Телефон",
+		"admin_full_name":         "ФИО администратора",
+		"admin_phone":             "Телефон администратора",
+		"admin_password":          "Пароль администратора",
+		"full_name":               "ФИО",
+		"iin":                     "ИИН",
+		"birth_year":              "Год рождения",
+		"organization":            "организация",
+		"user":                    "пользователь",
+		"driver":                  "водитель",
+		"number":                  "Номер документа",
+		"category":                "Категория удостоверения",
+		"issued_at":               "Дата выдачи",
+		"expires_at":              "Дата окончания",
+		"file_ref":                "Скан документа",
+		"days":                    "Количество дней",
+		"document":                "документ",
+		"vehicle":                 "техника",
+		"plate_number":            "Госномер",
+		"brand":                   "Марка",
+		"model":                   "Модель",
+		"color":                   "Цвет",
+		"year":                    "Год выпуска",
+		"body_volume_m3":          "Объём кузова, м³",
+		"bucket_volume_m3":        "Объём ковша, м³",
+		"payload_tonnes":          "Грузоподъёмность, т",
+		"vehicle_type_dump_truck": "Самосвал",
+		"vehicle_type_loader":     "Погрузчик",
+		"vehicle_type_grader":     "Грейдер",
+		"vehicle_type_sweeper":    "Подметально-уборочная машина",
+		"vehicle_type_tractor":    "Трактор",
+		"driver_id":               "Водитель",
+		"issuer":                  "Кем выдан",
+		"contract":                "договор",
+		"contractor_org_id":       "Исполнитель",
+		"start_date":              "Дата начала",
+		"end_date":                "Дата окончания",
+		"districts":               "Районы обслуживания",
+		"max_vehicles":            "Допустимое количество техники",
+		"status":                  "Статус",
+		"service_area":            "участок",
+		"organization_id":         "Организация",
+		"district":                "Район",
+		"boundary":                "Границы участка",
+		"lat":                     "Широта",
+		"lon":                     "Долгота",
+		"shift":                   "смена",
+		"vehicle_id":              "Техника",
+		"planned_start":           "Плановое начало",
+		"planned_end":             "Плановое окончание",
+		"note":                    "Примечание",
+		"at":                      "Момент времени",
+		"from":                    "Начало периода",
+		"to":                      "Конец периода",
+		"code":                    "Код подтверждения",
+		"ttl_minutes":             "Срок действия пропуска, мин",
+		"token":                   "Пропуск",
+		"url":                     "URL",
+		"event_types":             "Типы событий",
+		"scopes":                  "Разрешения",
+		"description":             "Описание",
+		"expires_in_days":         "Срок действия в днях",
+		"grace_period_hours":      "Переходный период в часах",
+	},
+	LangKK: {
+		"name":                    "Атауы",
+		"type":                    "Түрі",
+		"bin":                     "БСН",
+		"head_full_name":          "Басшының аты-жөні",
+		"address":                 "Мекенжайы",
+		"phone":                   "Телефон",
+		"admin_full_name":         "Әкімшінің аты-жөні",
+		"admin_phone":             "Әкімшінің телефоны",
+		"admin_password":          "Әкімшінің құпиясөзі",
+		"full_name":               "Аты-жөні",
+		"iin":                     "ЖСН",
+		"birth_year":              "Туған жылы",
+		"organization":            "ұйым",
+		"user":                    "пайдаланушы",
+		"driver":                  "жүргізуші",
+		"number":                  "Құжат нөмірі",
+		"category":                "Куәлік санаты",
+		"issued_at":               "Берілген күні",
+		"expires_at":              "Аяқталу күні",
+		"file_ref":                "Құжаттың сканері",
+		"days":                    "Күн саны",
+		"document":                "құжат",
+		"vehicle":                 "техника",
+		"plate_number":            "Мемлекеттік нөмір",
+		"brand":                   "Маркасы",
+		"model":                   "Моделі",
+		"color":                   "Түсі",
+		"year":                    "Шыққан жылы",
+		"body_volume_m3":          "Шанақ көлемі, м³",
+		"bucket_volume_m3":        "Шөміш көлемі, м³",
+		"payload_tonnes":          "Жүк көтергіштігі, т",
+		"vehicle_type_dump_truck": "Өздігінен төгетін көлік",
+		"vehicle_type_loader":     "Тиегіш",
+		"vehicle_type_grader":     "Грейдер",
+		"vehicle_type_sweeper":    "Сыпырғыш машина",
+		"vehicle_type_tractor":    "Трактор",
+		"driver_id":               "Жүргізуші",
+		"issuer":                  "Кім берді",
+		"contract":                "шарт",
+		"contractor_org_id":       "Орындаушы",
+		"start_date":              "Басталу күні",
+		"end_date":                "Аяқталу күні",
+		"districts":               "Қызмет көрсету аудандары",
+		"max_vehicles":            "Техниканың рұқсат етілген саны",
+		"status":                  "Мәртебесі",
+		"service_area":            "учаске",
+		"organization_id":         "Ұйым",
+		"district":                "Аудан",
+		"boundary":                "Учаске шекарасы",
+		"lat":                     "Ендік",
+		"lon":                     "Бойлық",
+		"shift":                   "ауысым",
+		"vehicle_id":              "Техника",
+		"planned_start":           "Жоспарлы басталуы",
+		"planned_end":             "Жоспарлы аяқталуы",
+		"note":                    "Ескертпе",
+		"at":                      "Уақыт сәті",
+		"from":                    "Кезең басы",
+		"to":                      "Кезең соңы",
+		"code":                    "Растау коды",
+		"ttl_minutes":             "Рұқсатнаманың қолданылу мерзімі, мин",
+		"token":                   "Рұқсатнама",
+		"url":                     "URL",
+		"event_types":             "Оқиға түрлері",
+		"scopes":                  "Рұқсаттар",
+		"description":             "Сипаттамасы",
+		"expires_in_days":         "Жарамдылық мерзімі (күн)",
+		"grace_period_hours":      "Өтпелі кезең (сағат)",
+	},
+}
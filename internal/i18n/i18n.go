@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang — язык ответа API.
+type Lang string
+
+// Поддерживаемые языки.
+const (
+	LangRU Lang = "ru"
+	LangKK Lang = "kk"
+	LangEN Lang = "en"
+)
+
+// DefaultLang используется, если клиент не указал поддерживаемый язык.
+const DefaultLang = LangEN
+
+// Label — имя поля или сущности, которое при форматировании сообщения
+// заменяется понятной пользователю подписью.
+type Label string
+
+// Negotiate выбирает язык по заголовку Accept-Language с учётом q-весов.
+func Negotiate(acceptLanguage string) Lang {
+	type candidate struct {
+		lang  Lang
+		q     float64
+		order int
+	}
+
+	var candidates []candidate
+	for i, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		base := strings.SplitN(tag, "-", 2)[0]
+		lang := Lang(base)
+		if tag == "*" {
+			lang = DefaultLang
+		}
+		if _, ok := catalogs[lang]; !ok {
+			continue
+		}
+
+		candidates = append(candidates, candidate{lang: lang, q: q, order: i})
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLang
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].order < candidates[j].order
+	})
+
+	return candidates[0].lang
+}
+
+// T переводит сообщение. Ключом служит английский текст сообщения; аргументы
+// типа Label заменяются подписями на выбранном языке.
+func T(lang Lang, key string, args ...interface{}) string {
+	message := key
+	if translated, ok := catalogs[lang][key]; ok {
+		message = translated
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if label, ok := arg.(Label); ok {
+			localized[i] = LabelFor(lang, string(label))
+			continue
+		}
+		localized[i] = arg
+	}
+
+	return fmt.Sprintf(message, localized...)
+}
+
+// Translated проверяет, есть ли в каталоге перевод сообщения на язык lang.
+func Translated(lang Lang, key string) bool {
+	_, ok := catalogs[lang][key]
+	return ok
+}
+
+// LabelFor возвращает понятную подпись поля или сущности на выбранном языке.
+func LabelFor(lang Lang, name string) string {
+	if label, ok := labels[lang][name]; ok {
+		return label
+	}
+	if label, ok := labels[DefaultLang][name]; ok {
+		return label
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+)
+
+// probePaths — служебные маршруты проб и метрик; успешные запросы к ним пишутся
+// только на уровне debug.
+var probePaths = map[string]bool{
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Middleware заменяет стандартный журнал gin: кладёт в контекст запроса данные
+// для записей журнала и после обработки пишет одну запись о запросе.
+// Должен стоять после RequestIDMiddleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		fields := &requestFields{requestID: c.GetString("requestID")}
+		c.Request = c.Request.WithContext(withFields(c.Request.Context(), fields))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case probePaths[c.Request.URL.Path]:
+			level = slog.LevelDebug
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("size", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if code, ok := c.Get(apierror.ContextKey); ok {
+			attrs = append(attrs, slog.Any("error_code", code))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "запрос обработан", attrs...)
+	}
+}
+
+// SetUser добавляет пользователя, роль и организацию в записи журнала запроса.
+// Вызывается middleware аутентификации.
+func SetUser(c *gin.Context, userID, role, orgID string) {
+	fields := fieldsFrom(c.Request.Context())
+	if fields == nil {
+		return
+	}
+	fields.userID = userID
+	fields.role = role
+	fields.orgID = orgID
+}
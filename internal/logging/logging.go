@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Level — текущий уровень журнала; может меняться на лету.
+var Level = new(slog.LevelVar)
+
+// Init настраивает журнал в формате JSON в stdout и делает его журналом по
+// умолчанию, в том числе для стандартного пакета log.
+func Init(level slog.Level) {
+	Level.Set(level)
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       Level,
+		ReplaceAttr: redactAttr,
+	})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// Fatal пишет ошибку в журнал и завершает процесс.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// requestFields — данные запроса, которые добавляются в каждую запись журнала,
+// сделанную с его контекстом. Пользователь заполняется после аутентификации.
+type requestFields struct {
+	requestID string
+	userID    string
+	role      string
+	orgID     string
+}
+
+type fieldsKey struct{}
+
+func withFields(ctx context.Context, fields *requestFields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+func fieldsFrom(ctx context.Context) *requestFields {
+	fields, _ := ctx.Value(fieldsKey{}).(*requestFields)
+	return fields
+}
+
+// contextHandler дополняет записи идентификатором запроса, пользователем и
+// идентификатором трассировки из контекста.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := fieldsFrom(ctx); fields != nil {
+		record.AddAttrs(slog.String("request_id", fields.requestID))
+		if fields.userID != "" {
+			record.AddAttrs(
+				slog.String("user_id", fields.userID),
+				slog.String("role", fields.role),
+				slog.String("org_id", fields.orgID),
+			)
+		}
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// ИИН — 12 цифр; дефис перед ними не допускается, чтобы не задеть последний блок UUID.
+	iinPattern = regexp.MustCompile(`(^|[^\w-])\d{12}\b`)
+	// Казахстанские номера телефонов: +7, 7 или 8 и десять цифр, возможно с разделителями.
+	phonePattern  = regexp.MustCompile(`(^|[^\w-])(\+?[78])[\s\-(]*\d{3}[\s\-)]*\d{3}[\s\-]*\d{2}[\s\-]*(\d{2})\b`)
+	tokenPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)bearer\s+\S+`),
+		regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]*`),
+		regexp.MustCompile(`SP1\.[\w-]+\.[\w-]+`),
+		regexp.MustCompile(`whsec_[0-9a-fA-F]+`),
+		regexp.MustCompile(`sok_[0-9a-f]+_[0-9a-f]+`),
+	}
+)
+
+// secretKeys — ключи, значения которых не пишутся в журнал совсем.
+var secretKeys = []string{"token", "secret", "password", "authorization", "signature", "api_key", "otp"}
+
+// secretExactKeys совпадают только целиком: "code" — код подтверждения, а не
+// status_code или error_code.
+var secretExactKeys = []string{"code", "verification_code"}
+
+// redactAttr скрывает телефоны, ИИН и токены: по имени поля и по содержимому строк,
+// включая текст сообщений и ошибок.
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	if slices.Contains(secretExactKeys, key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	value := attr.Value.Resolve()
+	var text string
+	switch value.Kind() {
+	case slog.KindString:
+		text = value.String()
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		default:
+			return attr
+		}
+	default:
+		return attr
+	}
+
+	switch {
+	case key == "iin":
+		return slog.String(attr.Key, maskTail(text))
+	case strings.Contains(key, "phone"):
+		return slog.String(attr.Key, maskTail(text))
+	}
+	return slog.String(attr.Key, Redact(text))
+}
+
+// Redact скрывает в произвольном тексте ИИН, номера телефонов и токены.
+func Redact(text string) string {
+	for _, pattern := range tokenPatterns {
+		text = pattern.ReplaceAllString(text, redacted)
+	}
+	text = iinPattern.ReplaceAllString(text, "${1}[IIN]")
+	text = phonePattern.ReplaceAllString(text, "${1}${2}******${3}")
+	return text
+}
+
+// maskTail оставляет последние четыре символа значения, чтобы записи можно было сопоставить.
+func maskTail(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// businessScrapeTimeout ограничивает время запросов к базе при сборе метрик.
+const businessScrapeTimeout = 5 * time.Second
+
+var (
+	activeDriversDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "active_drivers"),
+		"Active drivers per contractor.",
+		[]string{"contractor_id"}, nil,
+	)
+	activeVehiclesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "active_vehicles"),
+		"Active vehicles per contractor.",
+		[]string{"contractor_id"}, nil,
+	)
+	outboxPendingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "outbox_pending_events"),
+		"Domain events waiting for delivery.",
+		nil, nil,
+	)
+	webhookDeliveriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "webhook_deliveries"),
+		"Webhook deliveries that are pending or failed.",
+		[]string{"status"}, nil,
+	)
+)
+
+// businessCollector считает показатели предметной области при каждом сборе метрик.
+type businessCollector struct {
+	db *gorm.DB
+}
+
+// RegisterBusiness подключает бизнес-метрики, вычисляемые по базе данных.
+func RegisterBusiness(db *gorm.DB) error {
+	return Registry.Register(businessCollector{db: db})
+}
+
+func (businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeDriversDesc
+	ch <- activeVehiclesDesc
+	ch <- outboxPendingDesc
+	ch <- webhookDeliveriesDesc
+}
+
+type contractorCount struct {
+	ContractorID uuid.UUID
+	Count        int64
+}
+
+func (b businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessScrapeTimeout)
+	defer cancel()
+	db := b.db.WithContext(ctx)
+
+	collectPerContractor(ch, db.Model(&models.Driver{}), activeDriversDesc)
+	collectPerContractor(ch, db.Model(&models.Vehicle{}), activeVehiclesDesc)
+
+	var pending int64
+	if err := db.Model(&models.OutboxEvent{}).Where("delivered_at IS NULL").Count(&pending).Error; err != nil {
+		slog.Error("не удалось посчитать события outbox для метрик", "error", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(outboxPendingDesc, prometheus.GaugeValue, float64(pending))
+	}
+
+	for _, status := range []string{models.WebhookDeliveryPending, models.WebhookDeliveryFailed} {
+		var count int64
+		if err := db.Model(&models.WebhookDelivery{}).Where("status = ?", status).Count(&count).Error; err != nil {
+			slog.Error("не удалось посчитать доставки webhook для метрик", "error", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(webhookDeliveriesDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+func collectPerContractor(ch chan<- prometheus.Metric, q *gorm.DB, desc *prometheus.Desc) {
+	var rows []contractorCount
+	if err := q.Select("contractor_id, COUNT(*) AS count").
+		Where("is_active = ? AND contractor_id IS NOT NULL", true).
+		Group("contractor_id").
+		Scan(&rows).Error; err != nil {
+		slog.Error("не удалось собрать метрику", "metric", desc.String(), "error", err)
+		return
+	}
+
+	for _, row := range rows {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(row.Count), row.ContractorID.String())
+	}
+}
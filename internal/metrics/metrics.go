@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+const namespace = "snowops"
+
+// Registry содержит все метрики сервиса. Метки ограничены шаблонами маршрутов,
+// кодами ошибок, ролями и организациями-подрядчиками — идентификаторы
+// пользователей и запросов в метки не попадают.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "API error responses by error code.",
+	}, []string{"code"})
+
+	authOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_outcomes_total",
+		Help:      "Authentication and authorization outcomes by role.",
+	}, []string{"outcome", "role"})
+)
+
+// authOutcomeByCode сопоставляет коды ошибок исходам аутентификации и авторизации.
+var authOutcomeByCode = map[apierror.Code]string{
+	apierror.CodeUnauthorized:      "unauthorized",
+	apierror.CodeMissingToken:      "missing_token",
+	apierror.CodeInvalidToken:      "invalid_token",
+	apierror.CodeAuthMisconfigured: "misconfigured",
+	apierror.CodeAccountDisabled:   "account_disabled",
+	apierror.CodeForbidden:         "forbidden_role",
+	apierror.CodeForbiddenScope:    "forbidden_scope",
+	apierror.CodeContractRequired:  "contract_required",
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		apiErrors,
+		authOutcomes,
+		dbQueryDuration,
+		dbQueryErrors,
+	)
+}
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() gin.HandlerFunc {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	return gin.WrapH(h)
+}
+
+// GinMiddleware учитывает запросы, их длительность, коды ошибок и исходы авторизации.
+// Маршрут берётся из шаблона gin (/api/v1/drivers/:id), а не из фактического пути.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+
+		role := "none"
+		if principal, ok := auth.FromGin(c); ok {
+			role = roleLabel(principal.Role)
+		}
+		code, _ := c.Get(apierror.ContextKey)
+		if code, ok := code.(apierror.Code); ok {
+			apiErrors.WithLabelValues(string(code)).Inc()
+			if outcome, ok := authOutcomeByCode[code]; ok {
+				authOutcomes.WithLabelValues(outcome, role).Inc()
+				return
+			}
+		}
+
+		if role != "none" && c.Writer.Status() != http.StatusUnauthorized {
+			authOutcomes.WithLabelValues("authenticated", role).Inc()
+		}
+	}
+}
+
+// roleLabel ограничивает метку роли известными значениями: при фиктивной
+// аутентификации роль приходит из заголовка и может быть произвольной.
+func roleLabel(role string) string {
+	switch role {
+	case "":
+		return "none"
+	case models.RoleAkimatAdmin, models.RoleTooAdmin, models.RoleContractorAdmin, models.RoleDriver:
+		return role
+	default:
+		return "other"
+	}
+}
@@ -1,13 +1,18 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/logging"
+	"github.com/MSTimX/Snowops-roles/internal/tracing"
 )
 
 type UserClaims struct {
@@ -17,60 +22,110 @@ type UserClaims struct {
 	jwt.RegisteredClaims
 }
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+// APIKeyHeader — заголовок с API-ключом служебной учётной записи. Ключ также
+// принимается в Authorization: Bearer.
+const APIKeyHeader = "X-API-Key"
+
+// JWTAuthMiddleware проверяет токен HS256, подписанный secret, или API-ключ
+// служебной учётной записи и через resolver загружает субъекта из базы: роль и
+// организация берутся оттуда, а отключённые пользователи, учётные записи и
+// организации отклоняются даже с валидными учётными данными.
+func JWTAuthMiddleware(secret string, resolver *auth.Resolver) gin.HandlerFunc {
+	key := []byte(secret)
 	return func(c *gin.Context) {
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			errMsg := fmt.Sprintf("environment variable %s not set", "JWT_SECRET")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": errMsg})
-			c.Abort()
-			return
+		credential := strings.TrimSpace(c.GetHeader(APIKeyHeader))
+		if credential == "" {
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" {
+				apierror.Respond(c, apierror.New(apierror.CodeMissingToken))
+				return
+			}
+			if !strings.HasPrefix(authHeader, "Bearer ") {
+				apierror.Respond(c, apierror.New(apierror.CodeInvalidToken))
+				return
+			}
+			credential = strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
 		}
-
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-			c.Abort()
+		if credential == "" {
+			apierror.Respond(c, apierror.New(apierror.CodeInvalidToken))
 			return
 		}
 
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
-		}
+		var principal *auth.Principal
+		var err error
+		if auth.IsAPIKey(credential) {
+			span := tracing.StartAuth(c, "auth.api_key")
+			defer tracing.EndAuth(c, span)
 
-		tokenString := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
-		}
+			principal, err = resolver.ResolveAPIKey(c.Request.Context(), credential)
+			if !respondResolveError(c, err) {
+				return
+			}
+			tracing.SetPrincipal(c, span, principal.Subject(), principal.Role, principal.OrganizationID.String())
+		} else {
+			span := tracing.StartAuth(c, "auth.jwt")
+			defer tracing.EndAuth(c, span)
 
-		token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || method != jwt.SigningMethodHS256 {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			if len(key) == 0 {
+				apierror.Respond(c, apierror.New(apierror.CodeAuthMisconfigured))
+				return
+			}
+			userID, ok := parseUserToken(key, credential)
+			if !ok {
+				apierror.Respond(c, apierror.New(apierror.CodeInvalidToken))
+				return
 			}
-			return []byte(secret), nil
-		})
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
+			principal, err = resolver.Resolve(c.Request.Context(), userID)
+			if !respondResolveError(c, err) {
+				return
+			}
+			tracing.SetPrincipal(c, span, principal.Subject(), principal.Role, principal.OrganizationID.String())
 		}
 
-		claims, ok := token.Claims.(*UserClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
+		auth.SetGin(c, principal)
+		logging.SetUser(c, principal.Subject(), principal.Role, principal.OrganizationID.String())
+		// c.Next() не вызывается: спан аутентификации закрывается при выходе,
+		// а обработчики gin запустит сам.
+	}
+}
+
+// parseUserToken проверяет подпись и срок JWT и возвращает идентификатор пользователя.
+func parseUserToken(key []byte, tokenString string) (uuid.UUID, bool) {
+	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return key, nil
+	})
+	if err != nil || !token.Valid {
+		return uuid.Nil, false
+	}
+
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
 
-		c.Set("currentUserID", claims.UserID)
-		c.Set("currentUserRole", claims.Role)
-		c.Set("currentOrgID", claims.OrganizationID)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
 
-		c.Next()
+// respondResolveError отвечает ошибкой, если субъекта не удалось загрузить,
+// и возвращает true, если запрос можно продолжать.
+func respondResolveError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrUserNotFound), errors.Is(err, auth.ErrAPIKeyInvalid):
+		apierror.Respond(c, apierror.New(apierror.CodeInvalidToken))
+	case errors.Is(err, auth.ErrUserInactive), errors.Is(err, auth.ErrServiceAccountInactive), errors.Is(err, auth.ErrOrgInactive):
+		apierror.Respond(c, apierror.New(apierror.CodeAccountDisabled))
+	default:
+		apierror.Respond(c, apierror.Internal("resolve principal", err))
 	}
+	return false
 }
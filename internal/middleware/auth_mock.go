@@ -1,29 +1,54 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+	"github.com/MSTimX/Snowops-roles/internal/auth"
+	"github.com/MSTimX/Snowops-roles/internal/logging"
+	"github.com/MSTimX/Snowops-roles/internal/tracing"
 )
 
-// MockAuthMiddleware обеспечивает фиктивную аутентификацию, читая заголовки запроса.
+// MockAuthMiddleware обеспечивает фиктивную аутентификацию, читая заголовки запроса:
+// X-User-ID, X-User-Role и X-Org-ID обязательны, X-Org-Type и X-Driver-ID — нет.
+// Используется только в окружении development; каждый ответ помечается
+// заголовком X-Auth-Mode: mock.
 func MockAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := c.GetHeader("X-User-ID")
-		userRole := c.GetHeader("X-User-Role")
-		orgID := c.GetHeader("X-Org-ID")
+		c.Header("X-Auth-Mode", "mock")
+
+		span := tracing.StartAuth(c, "auth.mock")
+		defer tracing.EndAuth(c, span)
 
-		if userID == "" || userRole == "" || orgID == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "unauthorized",
-			})
+		userID, userErr := uuid.Parse(c.GetHeader("X-User-ID"))
+		orgID, orgErr := uuid.Parse(c.GetHeader("X-Org-ID"))
+		userRole := c.GetHeader("X-User-Role")
+		if userErr != nil || orgErr != nil || userRole == "" {
+			apierror.Respond(c, apierror.New(apierror.CodeUnauthorized))
 			return
 		}
 
-		c.Set("currentUserID", userID)
-		c.Set("currentUserRole", userRole)
-		c.Set("currentOrgID", orgID)
+		principal := &auth.Principal{
+			UserID:           userID,
+			Role:             userRole,
+			OrganizationID:   orgID,
+			OrganizationType: c.GetHeader("X-Org-Type"),
+			Scopes:           []string{auth.ScopeAll},
+		}
+		if raw := c.GetHeader("X-Driver-ID"); raw != "" {
+			driverID, err := uuid.Parse(raw)
+			if err != nil {
+				apierror.Respond(c, apierror.New(apierror.CodeUnauthorized))
+				return
+			}
+			principal.DriverID = &driverID
+		}
 
-		c.Next()
+		auth.SetGin(c, principal)
+		tracing.SetPrincipal(c, span, userID.String(), userRole, orgID.String())
+		logging.SetUser(c, userID.String(), userRole, orgID.String())
+		// c.Next() не вызывается: спан аутентификации закрывается при выходе,
+		// а обработчики gin запустит сам.
 	}
 }
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MSTimX/Snowops-roles/internal/apierror"
+)
+
+// RecoveryMiddleware перехватывает панику в обработчике, пишет её в журнал со
+// стеком и отвечает INTERNAL_ERROR в едином формате.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "паника при обработке запроса",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()),
+		)
+		apierror.Respond(c, apierror.New(apierror.CodeInternal))
+	})
+}
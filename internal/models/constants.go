@@ -56,3 +56,62 @@ func IsContractorAdmin(role string) bool {
 func IsDriver(role string) bool {
 	return role == RoleDriver
 }
+
+// Типы документов водителя.
+const (
+	DriverDocLicense            = "DRIVER_LICENSE"
+	DriverDocMedicalCertificate = "MEDICAL_CERTIFICATE"
+)
+
+// Категории водительского удостоверения.
+const (
+	LicenseCategoryB   = "B"
+	LicenseCategoryC   = "C"
+	LicenseCategoryC1  = "C1"
+	LicenseCategoryCE  = "CE"
+	LicenseCategoryC1E = "C1E"
+	LicenseCategoryD   = "D"
+)
+
+// MandatoryDriverDocuments перечисляет документы, без которых водитель не допускается к работе.
+var MandatoryDriverDocuments = []string{DriverDocLicense, DriverDocMedicalCertificate}
+
+// SnowTruckLicenseCategories — категории, дающие право управлять снегоуборочным грузовиком.
+var SnowTruckLicenseCategories = []string{LicenseCategoryC, LicenseCategoryCE}
+
+// IsDriverDocumentType проверяет, поддерживается ли тип документа водителя.
+func IsDriverDocumentType(docType string) bool {
+	switch docType {
+	case DriverDocLicense, DriverDocMedicalCertificate:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLicenseCategory проверяет, является ли строка известной категорией удостоверения.
+func IsLicenseCategory(category string) bool {
+	switch category {
+	case LicenseCategoryB, LicenseCategoryC, LicenseCategoryC1, LicenseCategoryCE, LicenseCategoryC1E, LicenseCategoryD:
+		return true
+	default:
+		return false
+	}
+}
+
+// Типы документов транспортного средства.
+const (
+	VehicleDocRegistration        = "REGISTRATION_CERTIFICATE"
+	VehicleDocTechnicalInspection = "TECHNICAL_INSPECTION"
+	VehicleDocInsuranceOGPO       = "INSURANCE_OGPO"
+)
+
+// VehicleDocumentRequiresExpiry проверяет, должен ли документ техники иметь срок действия.
+func VehicleDocumentRequiresExpiry(docType string) bool {
+	switch docType {
+	case VehicleDocTechnicalInspection, VehicleDocInsuranceOGPO:
+		return true
+	default:
+		return false
+	}
+}
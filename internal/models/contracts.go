@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы договора.
+const (
+	ContractStatusDraft      = "DRAFT"
+	ContractStatusActive     = "ACTIVE"
+	ContractStatusSuspended  = "SUSPENDED"
+	ContractStatusTerminated = "TERMINATED"
+)
+
+// Contract — договор заказчика (акимат или ТОО) с исполнителем (ТОО или подрядчик).
+// Акимат заключает договоры с ТОО, ТОО — со своими подрядчиками.
+type Contract struct {
+	ID              uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Number          string        `gorm:"type:varchar(64);uniqueIndex:idx_contracts_customer_number"`
+	CustomerOrgID   uuid.UUID     `gorm:"type:uuid;uniqueIndex:idx_contracts_customer_number"`
+	CustomerOrg     *Organization `gorm:"foreignKey:CustomerOrgID;constraint:OnDelete:CASCADE"`
+	ContractorOrgID uuid.UUID     `gorm:"type:uuid;index"`
+	ContractorOrg   *Organization `gorm:"foreignKey:ContractorOrgID;constraint:OnDelete:CASCADE"`
+	StartDate       time.Time     `gorm:"type:date"`
+	EndDate         time.Time     `gorm:"type:date;index"`
+	Districts       []string      `gorm:"type:jsonb;serializer:json"`
+	// MaxVehicles — допустимое число активной техники исполнителя; 0 — без ограничения.
+	MaxVehicles int    `gorm:"type:int"`
+	Status      string `gorm:"type:varchar(32);index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Contract) TableName() string {
+	return "contracts"
+}
+
+// InForce проверяет, действует ли договор на дату now: он активен, и дата
+// попадает в период действия включительно.
+func (c Contract) InForce(now time.Time) bool {
+	today := Today(now)
+	return c.Status == ContractStatusActive &&
+		!today.Before(startOfDay(c.StartDate)) &&
+		!today.After(startOfDay(c.EndDate))
+}
+
+// IsContractStatus проверяет, является ли строка известным статусом договора.
+func IsContractStatus(status string) bool {
+	switch status {
+	case ContractStatusDraft, ContractStatusActive, ContractStatusSuspended, ContractStatusTerminated:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// useAlmaty переключает часовой пояс сервиса на Asia/Almaty на время теста.
+func useAlmaty(t *testing.T) {
+	t.Helper()
+	location, err := time.LoadLocation("Asia/Almaty")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	previous := Location
+	Location = location
+	t.Cleanup(func() { Location = previous })
+}
+
+func TestContractInForceUsesServiceDate(t *testing.T) {
+	useAlmaty(t)
+
+	// 19:30 UTC 31 марта — уже 1 апреля в Алматы, 17:30 UTC — ещё 31 марта.
+	lateEvening := time.Date(2026, 3, 31, 19, 30, 0, 0, time.UTC)
+	evening := time.Date(2026, 3, 31, 17, 30, 0, 0, time.UTC)
+	march31 := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	april1 := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	startsApril1 := Contract{Status: ContractStatusActive, StartDate: april1, EndDate: april1.AddDate(0, 6, 0)}
+	endsMarch31 := Contract{Status: ContractStatusActive, StartDate: march31.AddDate(0, -6, 0), EndDate: march31}
+
+	cases := []struct {
+		name     string
+		contract Contract
+		now      time.Time
+		want     bool
+	}{
+		{"start date reached in Almaty", startsApril1, lateEvening, true},
+		{"day before start date", startsApril1, evening, false},
+		{"end date in Almaty", endsMarch31, evening, true},
+		{"end date passed in Almaty", endsMarch31, lateEvening, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.contract.InForce(tc.now); got != tc.want {
+				t.Errorf("InForce(%s) = %v, want %v", tc.now, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToday(t *testing.T) {
+	useAlmaty(t)
+
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 3, 31, 17, 30, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 31, 19, 30, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		if got := Today(tc.now); !got.Equal(tc.want) || got.Location() != time.UTC {
+			t.Errorf("Today(%s) = %s, want %s", tc.now, got, tc.want)
+		}
+	}
+}
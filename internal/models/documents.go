@@ -0,0 +1,205 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DriverDocument struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DriverID  uuid.UUID `gorm:"type:uuid;index"`
+	Driver    *Driver   `gorm:"foreignKey:DriverID;constraint:OnDelete:CASCADE"`
+	Type      string    `gorm:"type:varchar(50);index"`
+	Number    string    `gorm:"type:varchar(64)"`
+	Category  string    `gorm:"type:varchar(16)"`
+	IssuedAt  time.Time `gorm:"type:date"`
+	ExpiresAt time.Time `gorm:"type:date;index"`
+	FileRef   string    `gorm:"type:varchar(512)"`
+	IsActive  bool      `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (DriverDocument) TableName() string {
+	return "driver_documents"
+}
+
+// IsExpired проверяет, истёк ли документ на момент now. Документ действует
+// включительно по дату окончания.
+func (d DriverDocument) IsExpired(now time.Time) bool {
+	return Today(now).After(startOfDay(d.ExpiresAt))
+}
+
+// Clearance — результат проверки допуска водителя или техники к работе.
+type Clearance struct {
+	Cleared bool
+	Issues  []string
+}
+
+// Причины недопуска водителя к работе.
+const (
+	ClearanceIssueMissingLicense  = "MISSING_DRIVER_LICENSE"
+	ClearanceIssueExpiredLicense  = "EXPIRED_DRIVER_LICENSE"
+	ClearanceIssueLicenseCategory = "LICENSE_CATEGORY_NOT_ALLOWED"
+	ClearanceIssueMissingMedical  = "MISSING_MEDICAL_CERTIFICATE"
+	ClearanceIssueExpiredMedical  = "EXPIRED_MEDICAL_CERTIFICATE"
+	ClearanceIssueDriverInactive  = "DRIVER_INACTIVE"
+)
+
+// EvaluateDriverClearance определяет, допущен ли водитель к работе на
+// снегоуборочном грузовике: он должен быть активен и иметь действующие
+// удостоверение категории C/CE и медицинскую справку.
+func EvaluateDriverClearance(driver Driver, docs []DriverDocument, now time.Time) Clearance {
+	return EvaluateDriverClearanceFor(driver, docs, "", now)
+}
+
+// EvaluateDriverClearanceFor определяет допуск водителя к технике заданного типа:
+// категория удостоверения берётся из справочника типов техники. Если удостоверение
+// нужной категории есть, но истекло, причина — EXPIRED_DRIVER_LICENSE, а не
+// неподходящая категория.
+func EvaluateDriverClearanceFor(driver Driver, docs []DriverDocument, vehicleType string, now time.Time) Clearance {
+	var issues []string
+	if !driver.IsActive {
+		issues = append(issues, ClearanceIssueDriverInactive)
+	}
+
+	categories := RequiredLicenseCategories(vehicleType)
+
+	var hasLicense, hasCoveringLicense, hasValidCoveringLicense, hasMedical, hasValidMedical bool
+	for _, doc := range docs {
+		if !doc.IsActive || doc.DriverID != driver.ID {
+			continue
+		}
+
+		switch doc.Type {
+		case DriverDocLicense:
+			hasLicense = true
+			if !slices.Contains(categories, doc.Category) {
+				continue
+			}
+			hasCoveringLicense = true
+			if !doc.IsExpired(now) {
+				hasValidCoveringLicense = true
+			}
+		case DriverDocMedicalCertificate:
+			hasMedical = true
+			if !doc.IsExpired(now) {
+				hasValidMedical = true
+			}
+		}
+	}
+
+	switch {
+	case !hasLicense:
+		issues = append(issues, ClearanceIssueMissingLicense)
+	case !hasCoveringLicense:
+		issues = append(issues, ClearanceIssueLicenseCategory)
+	case !hasValidCoveringLicense:
+		issues = append(issues, ClearanceIssueExpiredLicense)
+	}
+
+	switch {
+	case !hasMedical:
+		issues = append(issues, ClearanceIssueMissingMedical)
+	case !hasValidMedical:
+		issues = append(issues, ClearanceIssueExpiredMedical)
+	}
+
+	return Clearance{Cleared: len(issues) == 0, Issues: issues}
+}
+
+type VehicleDocument struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	VehicleID uuid.UUID  `gorm:"type:uuid;index"`
+	Vehicle   *Vehicle   `gorm:"foreignKey:VehicleID;constraint:OnDelete:CASCADE"`
+	Type      string     `gorm:"type:varchar(50);index"`
+	Number    string     `gorm:"type:varchar(64)"`
+	Issuer    string     `gorm:"type:varchar(255)"`
+	IssuedAt  time.Time  `gorm:"type:date"`
+	ExpiresAt *time.Time `gorm:"type:date;index"`
+	FileRef   string     `gorm:"type:varchar(512)"`
+	IsActive  bool       `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (VehicleDocument) TableName() string {
+	return "vehicle_documents"
+}
+
+// IsExpired проверяет, истёк ли документ на момент now. Бессрочные документы
+// (например, свидетельство о регистрации) не истекают.
+func (d VehicleDocument) IsExpired(now time.Time) bool {
+	if d.ExpiresAt == nil {
+		return false
+	}
+	return Today(now).After(startOfDay(*d.ExpiresAt))
+}
+
+// Причины недопуска техники к работе.
+const (
+	ClearanceIssueVehicleInactive     = "VEHICLE_INACTIVE"
+	ClearanceIssueMissingRegistration = "MISSING_REGISTRATION_CERTIFICATE"
+	ClearanceIssueMissingInspection   = "MISSING_TECHNICAL_INSPECTION"
+	ClearanceIssueExpiredInspection   = "EXPIRED_TECHNICAL_INSPECTION"
+	ClearanceIssueMissingInsurance    = "MISSING_INSURANCE_OGPO"
+	ClearanceIssueExpiredInsurance    = "EXPIRED_INSURANCE_OGPO"
+)
+
+// EvaluateVehicleClearance определяет, допущена ли техника к работе: она должна быть
+// активна, зарегистрирована и иметь действующие техосмотр и полис ОГПО.
+func EvaluateVehicleClearance(vehicle Vehicle, docs []VehicleDocument, now time.Time) Clearance {
+	var issues []string
+	if !vehicle.IsActive {
+		issues = append(issues, ClearanceIssueVehicleInactive)
+	}
+
+	present := make(map[string]bool)
+	valid := make(map[string]bool)
+	for _, doc := range docs {
+		if !doc.IsActive || doc.VehicleID != vehicle.ID {
+			continue
+		}
+		present[doc.Type] = true
+		if !doc.IsExpired(now) {
+			valid[doc.Type] = true
+		}
+	}
+
+	if !present[VehicleDocRegistration] {
+		issues = append(issues, ClearanceIssueMissingRegistration)
+	}
+
+	switch {
+	case !present[VehicleDocTechnicalInspection]:
+		issues = append(issues, ClearanceIssueMissingInspection)
+	case !valid[VehicleDocTechnicalInspection]:
+		issues = append(issues, ClearanceIssueExpiredInspection)
+	}
+
+	switch {
+	case !present[VehicleDocInsuranceOGPO]:
+		issues = append(issues, ClearanceIssueMissingInsurance)
+	case !valid[VehicleDocInsuranceOGPO]:
+		issues = append(issues, ClearanceIssueExpiredInsurance)
+	}
+
+	return Clearance{Cleared: len(issues) == 0, Issues: issues}
+}
+
+// Location — часовой пояс сервиса (DB_TIMEZONE), в котором определяется текущая
+// календарная дата для сроков договоров и документов. Задаётся при старте сервиса.
+var Location = time.UTC
+
+// Today возвращает дату момента now в часовом поясе сервиса как полночь UTC — в
+// таком виде хранятся даты договоров и документов.
+func Today(now time.Time) time.Time {
+	return startOfDay(now.In(Location))
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEvaluateDriverClearanceFor(t *testing.T) {
+	now := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	expired := now.AddDate(0, -1, 0)
+	valid := now.AddDate(1, 0, 0)
+
+	driver := Driver{ID: uuid.New(), IsActive: true}
+	license := func(category string, expiresAt time.Time) DriverDocument {
+		return DriverDocument{DriverID: driver.ID, Type: DriverDocLicense, Category: category, ExpiresAt: expiresAt, IsActive: true}
+	}
+	medical := DriverDocument{DriverID: driver.ID, Type: DriverDocMedicalCertificate, ExpiresAt: valid, IsActive: true}
+
+	cases := []struct {
+		name        string
+		docs        []DriverDocument
+		vehicleType string
+		want        []string
+	}{
+		{"valid CE", []DriverDocument{license(LicenseCategoryCE, valid), medical}, VehicleTypeDumpTruck, nil},
+		{"expired CE and valid B", []DriverDocument{license(LicenseCategoryCE, expired), license(LicenseCategoryB, valid), medical}, VehicleTypeDumpTruck, []string{ClearanceIssueExpiredLicense}},
+		{"only B", []DriverDocument{license(LicenseCategoryB, valid), medical}, VehicleTypeDumpTruck, []string{ClearanceIssueLicenseCategory}},
+		{"C1 for dump truck", []DriverDocument{license(LicenseCategoryC1, valid), medical}, VehicleTypeDumpTruck, []string{ClearanceIssueLicenseCategory}},
+		{"C1 for sweeper", []DriverDocument{license(LicenseCategoryC1, valid), medical}, VehicleTypeSweeper, nil},
+		{"no documents", nil, "", []string{ClearanceIssueMissingLicense, ClearanceIssueMissingMedical}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := EvaluateDriverClearanceFor(driver, tc.docs, tc.vehicleType, now)
+			if !slices.Equal(got.Issues, tc.want) {
+				t.Errorf("issues = %v, want %v", got.Issues, tc.want)
+			}
+			if got.Cleared != (len(tc.want) == 0) {
+				t.Errorf("cleared = %v with issues %v", got.Cleared, got.Issues)
+			}
+		})
+	}
+}
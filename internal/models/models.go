@@ -10,7 +10,7 @@ type Organization struct {
 	ID           uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	Name         string        `gorm:"type:varchar(255)"`
 	Type         string        `gorm:"type:varchar(50)"`
-	BIN          string        `gorm:"type:varchar(32)"`
+	BIN          string        `gorm:"type:varchar(32);uniqueIndex:idx_organizations_bin_active,where:is_active = true AND bin <> ''"`
 	HeadFullName string        `gorm:"type:varchar(255)"`
 	Address      string        `gorm:"type:varchar(255)"`
 	Phone        string        `gorm:"type:varchar(32)"`
@@ -27,7 +27,7 @@ func (Organization) TableName() string {
 
 type User struct {
 	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	Phone          string        `gorm:"type:varchar(32);uniqueIndex"`
+	Phone          string        `gorm:"type:varchar(32);uniqueIndex:idx_users_phone_active,where:is_active = true"`
 	Role           string        `gorm:"type:varchar(50)"`
 	Login          *string       `gorm:"type:varchar(64)"`
 	PasswordHash   *string       `gorm:"type:varchar(255)"`
@@ -49,9 +49,9 @@ type Driver struct {
 	ContractorID *uuid.UUID    `gorm:"type:uuid"`
 	Contractor   *Organization `gorm:"foreignKey:ContractorID;constraint:OnDelete:SET NULL"`
 	FullName     string        `gorm:"type:varchar(255)"`
-	IIN          string        `gorm:"type:varchar(32)"`
+	IIN          string        `gorm:"type:varchar(32);uniqueIndex:idx_drivers_iin_active,where:is_active = true"`
 	BirthYear    int           `gorm:"type:int"`
-	Phone        string        `gorm:"type:varchar(32)"`
+	Phone        string        `gorm:"type:varchar(32);uniqueIndex:idx_drivers_phone_active,where:is_active = true"`
 	IsActive     bool          `gorm:"default:true"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -62,23 +62,26 @@ func (Driver) TableName() string {
 }
 
 type Vehicle struct {
-	ID           uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	ContractorID *uuid.UUID    `gorm:"type:uuid"`
-	Contractor   *Organization `gorm:"foreignKey:ContractorID;constraint:OnDelete:SET NULL"`
-	PlateNumber  string        `gorm:"type:varchar(32);uniqueIndex"`
-	Brand        string        `gorm:"type:varchar(64)"`
-	Model        string        `gorm:"type:varchar(64)"`
-	Color        string        `gorm:"type:varchar(64)"`
-	Year         int           `gorm:"type:int"`
-	BodyVolumeM3 float64       `gorm:"type:decimal(10,2)"`
-	DriverID     *uuid.UUID    `gorm:"type:uuid"`
-	Driver       *Driver       `gorm:"foreignKey:DriverID;constraint:OnDelete:SET NULL"`
-	IsActive     bool          `gorm:"default:true"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ContractorID   *uuid.UUID    `gorm:"type:uuid"`
+	Contractor     *Organization `gorm:"foreignKey:ContractorID;constraint:OnDelete:SET NULL"`
+	PlateNumber    string        `gorm:"type:varchar(32);uniqueIndex:idx_vehicles_plate_number_active,where:is_active = true"`
+	PlateDisplay   string        `gorm:"type:varchar(32)"`
+	Type           string        `gorm:"type:varchar(32);not null;default:'DUMP_TRUCK';index"`
+	Brand          string        `gorm:"type:varchar(64)"`
+	Model          string        `gorm:"type:varchar(64)"`
+	Color          string        `gorm:"type:varchar(64)"`
+	Year           int           `gorm:"type:int"`
+	BodyVolumeM3   float64       `gorm:"type:decimal(10,2)"`
+	BucketVolumeM3 float64       `gorm:"type:decimal(10,2)"`
+	PayloadTonnes  float64       `gorm:"type:decimal(10,2)"`
+	DriverID       *uuid.UUID    `gorm:"type:uuid"`
+	Driver         *Driver       `gorm:"foreignKey:DriverID;constraint:OnDelete:SET NULL"`
+	IsActive       bool          `gorm:"default:true"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 func (Vehicle) TableName() string {
 	return "vehicles"
 }
-
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent — доменное событие, записанное в одной транзакции с изменением
+// и ожидающее доставки. Sequence задаёт порядок событий внутри агрегата.
+type OutboxEvent struct {
+	Sequence       int64      `gorm:"primaryKey;autoIncrement"`
+	ID             uuid.UUID  `gorm:"type:uuid;uniqueIndex"`
+	AggregateType  string     `gorm:"type:varchar(50);index:idx_outbox_events_aggregate"`
+	AggregateID    uuid.UUID  `gorm:"type:uuid;index:idx_outbox_events_aggregate"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	Type           string     `gorm:"type:varchar(64);index"`
+	Payload        string     `gorm:"type:jsonb"`
+	OccurredAt     time.Time
+	Attempts       int        `gorm:"type:int;default:0"`
+	NextAttemptAt  time.Time  `gorm:"index"`
+	LastError      string     `gorm:"type:text"`
+	DeliveredAt    *time.Time `gorm:"index"`
+	CreatedAt      time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
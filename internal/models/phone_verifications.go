@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Параметры подтверждения нового номера телефона.
+const (
+	PhoneVerificationTTL         = 10 * time.Minute
+	PhoneVerificationMaxAttempts = 5
+)
+
+// PhoneVerification — запрос на смену номера телефона пользователем. Новый номер
+// применяется только после ввода кода, отправленного на него.
+type PhoneVerification struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;index"`
+	User        *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Phone       string    `gorm:"type:varchar(32)"`
+	CodeHash    string    `gorm:"type:varchar(255)"`
+	Attempts    int       `gorm:"type:int;default:0"`
+	ExpiresAt   time.Time
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (PhoneVerification) TableName() string {
+	return "phone_verifications"
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SchemaMigration отмечает версию схемы, до которой база приведена миграциями.
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Разрешения служебных учётных записей. Ресурс соответствует разделу API,
+// read — чтению (GET), write — изменениям.
+const (
+	ScopeContractsRead      = "contracts:read"
+	ScopeContractsWrite     = "contracts:write"
+	ScopeOrganizationsRead  = "organizations:read"
+	ScopeOrganizationsWrite = "organizations:write"
+	ScopeUsersRead          = "users:read"
+	ScopeUsersWrite         = "users:write"
+	ScopeDriversRead        = "drivers:read"
+	ScopeDriversWrite       = "drivers:write"
+	ScopeVehiclesRead       = "vehicles:read"
+	ScopeVehiclesWrite      = "vehicles:write"
+	ScopeServiceAreasRead   = "service_areas:read"
+	ScopeServiceAreasWrite  = "service_areas:write"
+	ScopeShiftsRead         = "shifts:read"
+	ScopeShiftsWrite        = "shifts:write"
+	ScopePassesWrite        = "passes:write"
+)
+
+// ServiceAccountScopes перечисляет разрешения, которые можно выдать служебной
+// учётной записи. Профиль (/me), webhook и сами служебные учётные записи
+// доступны только пользователям.
+var ServiceAccountScopes = []string{
+	ScopeContractsRead, ScopeContractsWrite,
+	ScopeOrganizationsRead, ScopeOrganizationsWrite,
+	ScopeUsersRead, ScopeUsersWrite,
+	ScopeDriversRead, ScopeDriversWrite,
+	ScopeVehiclesRead, ScopeVehiclesWrite,
+	ScopeServiceAreasRead, ScopeServiceAreasWrite,
+	ScopeShiftsRead, ScopeShiftsWrite,
+	ScopePassesWrite,
+}
+
+// IsServiceAccountScope проверяет, можно ли выдать разрешение служебной учётной записи.
+func IsServiceAccountScope(scope string) bool {
+	for _, s := range ServiceAccountScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminRoleForOrgType возвращает административную роль организации заданного
+// типа; от её имени действуют служебные учётные записи организации.
+func AdminRoleForOrgType(orgType string) string {
+	switch orgType {
+	case OrgTypeAkimat:
+		return RoleAkimatAdmin
+	case OrgTypeToo:
+		return RoleTooAdmin
+	case OrgTypeContractor:
+		return RoleContractorAdmin
+	default:
+		return ""
+	}
+}
+
+// ServiceAccount — учётная запись внешней системы (GPS-трекинг, биллинг),
+// привязанная к организации. Действует с правами администратора организации,
+// ограниченными списком Scopes.
+type ServiceAccount struct {
+	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name           string        `gorm:"type:varchar(255)"`
+	Description    string        `gorm:"type:text"`
+	OrganizationID uuid.UUID     `gorm:"type:uuid;not null;index"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE"`
+	Scopes         []string      `gorm:"type:jsonb;serializer:json"`
+	CreatedByID    *uuid.UUID    `gorm:"type:uuid"`
+	IsActive       bool          `gorm:"default:true;index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (ServiceAccount) TableName() string {
+	return "service_accounts"
+}
+
+// APIKey — ключ служебной учётной записи. Хранится только SHA-256 секретной
+// части; Prefix открыт и служит для поиска ключа и его опознания в интерфейсе.
+type APIKey struct {
+	ID               uuid.UUID       `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ServiceAccountID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ServiceAccount   *ServiceAccount `gorm:"foreignKey:ServiceAccountID;constraint:OnDelete:CASCADE"`
+	Name             string          `gorm:"type:varchar(255)"`
+	Prefix           string          `gorm:"type:varchar(32);uniqueIndex"`
+	Hash             string          `gorm:"type:varchar(64)"`
+	ExpiresAt        time.Time       `gorm:"index"`
+	LastUsedAt       *time.Time
+	RevokedAt        *time.Time
+	// RotatedToID указывает ключ, выпущенный взамен этого при ротации.
+	RotatedToID *uuid.UUID `gorm:"type:uuid"`
+	CreatedByID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt   time.Time
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsUsable проверяет, что ключ не отозван и не истёк к моменту now.
+func (k APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceArea — участок уборки, закреплённый за ТОО или подрядчиком. Участки
+// подрядчика выделяются из участков родительского ТОО (ParentAreaID).
+type ServiceArea struct {
+	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OrganizationID uuid.UUID     `gorm:"type:uuid;index"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE"`
+	ParentAreaID   *uuid.UUID    `gorm:"type:uuid;index"`
+	ParentArea     *ServiceArea  `gorm:"foreignKey:ParentAreaID;constraint:OnDelete:SET NULL"`
+	Name           string        `gorm:"type:varchar(255)"`
+	District       string        `gorm:"type:varchar(255);index"`
+	// Boundary хранит геометрию GeoJSON типа Polygon в координатах WGS 84.
+	Boundary string `gorm:"type:jsonb"`
+	// Ограничивающий прямоугольник для предварительного отбора участков в SQL.
+	MinLon    float64 `gorm:"type:double precision"`
+	MinLat    float64 `gorm:"type:double precision"`
+	MaxLon    float64 `gorm:"type:double precision"`
+	MaxLat    float64 `gorm:"type:double precision"`
+	IsActive  bool    `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (ServiceArea) TableName() string {
+	return "service_areas"
+}
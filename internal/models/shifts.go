@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы смены.
+const (
+	ShiftStatusPlanned   = "PLANNED"
+	ShiftStatusCancelled = "CANCELLED"
+)
+
+// MaxShiftDuration ограничивает длительность одной смены.
+const MaxShiftDuration = 24 * time.Hour
+
+// Shift — плановая смена водителя на технике подрядчика.
+type Shift struct {
+	ID           uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ContractorID uuid.UUID     `gorm:"type:uuid;index"`
+	Contractor   *Organization `gorm:"foreignKey:ContractorID;constraint:OnDelete:CASCADE"`
+	DriverID     uuid.UUID     `gorm:"type:uuid;index:idx_shifts_driver_period"`
+	Driver       *Driver       `gorm:"foreignKey:DriverID;constraint:OnDelete:CASCADE"`
+	VehicleID    uuid.UUID     `gorm:"type:uuid;index:idx_shifts_vehicle_period"`
+	Vehicle      *Vehicle      `gorm:"foreignKey:VehicleID;constraint:OnDelete:CASCADE"`
+	PlannedStart time.Time     `gorm:"index:idx_shifts_driver_period;index:idx_shifts_vehicle_period;index"`
+	PlannedEnd   time.Time     `gorm:"index"`
+	Status       string        `gorm:"type:varchar(32);index"`
+	Note         string        `gorm:"type:varchar(512)"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (Shift) TableName() string {
+	return "shifts"
+}
+
+// Overlaps проверяет пересечение плановых периодов; смены, стыкующиеся концом
+// к началу, не пересекаются.
+func (s Shift) Overlaps(start, end time.Time) bool {
+	return s.PlannedStart.Before(end) && start.Before(s.PlannedEnd)
+}
@@ -0,0 +1,64 @@
+package models
+
+// Типы снегоуборочной техники.
+const (
+	VehicleTypeDumpTruck = "DUMP_TRUCK"
+	VehicleTypeLoader    = "LOADER"
+	VehicleTypeGrader    = "GRADER"
+	VehicleTypeSweeper   = "SWEEPER"
+	VehicleTypeTractor   = "TRACTOR"
+)
+
+// VehicleTypeSpec описывает тип техники и применимые к нему характеристики.
+type VehicleTypeSpec struct {
+	Code string
+	// HaulsSnow — техника вывозит снег, поэтому для неё обязателен объём кузова.
+	HaulsSnow bool
+	// UsesBodyVolume, UsesBucketVolume и UsesPayload показывают, какие
+	// характеристики имеют смысл для типа.
+	UsesBodyVolume   bool
+	UsesBucketVolume bool
+	UsesPayload      bool
+	// LicenseCategories — категории удостоверения, дающие право управлять техникой.
+	LicenseCategories []string
+}
+
+// VehicleTypes — справочник типов техники в порядке отображения.
+var VehicleTypes = []VehicleTypeSpec{
+	{Code: VehicleTypeDumpTruck, HaulsSnow: true, UsesBodyVolume: true, UsesPayload: true, LicenseCategories: SnowTruckLicenseCategories},
+	{Code: VehicleTypeLoader, UsesBucketVolume: true, LicenseCategories: SnowTruckLicenseCategories},
+	{Code: VehicleTypeGrader, LicenseCategories: SnowTruckLicenseCategories},
+	{Code: VehicleTypeSweeper, LicenseCategories: []string{LicenseCategoryC1, LicenseCategoryC, LicenseCategoryC1E, LicenseCategoryCE}},
+	{Code: VehicleTypeTractor, UsesBucketVolume: true, LicenseCategories: SnowTruckLicenseCategories},
+}
+
+// LookupVehicleType возвращает описание типа техники по коду.
+func LookupVehicleType(code string) (VehicleTypeSpec, bool) {
+	for _, spec := range VehicleTypes {
+		if spec.Code == code {
+			return spec, true
+		}
+	}
+	return VehicleTypeSpec{}, false
+}
+
+// RequiredLicenseCategories возвращает категории удостоверения, достаточные для
+// техники заданного типа. Для неизвестного или пустого типа — категории
+// снегоуборочного грузовика.
+func RequiredLicenseCategories(vehicleType string) []string {
+	if spec, ok := LookupVehicleType(vehicleType); ok && len(spec.LicenseCategories) > 0 {
+		return spec.LicenseCategories
+	}
+	return SnowTruckLicenseCategories
+}
+
+// HaulingVehicleTypes возвращает коды типов, вывозящих снег.
+func HaulingVehicleTypes() []string {
+	var codes []string
+	for _, spec := range VehicleTypes {
+		if spec.HaulsSnow {
+			codes = append(codes, spec.Code)
+		}
+	}
+	return codes
+}
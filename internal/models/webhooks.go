@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы доставки webhook.
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryFailed    = "FAILED"
+)
+
+// WebhookSubscription — подписка внешней системы на доменные события.
+// Пустой EventTypes означает все события; OrganizationID ограничивает события
+// поддеревом организации (сама организация и её дочерние).
+type WebhookSubscription struct {
+	ID             uuid.UUID     `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name           string        `gorm:"type:varchar(255)"`
+	URL            string        `gorm:"type:varchar(2048)"`
+	EventTypes     []string      `gorm:"type:jsonb;serializer:json"`
+	OrganizationID *uuid.UUID    `gorm:"type:uuid;index"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE"`
+	// Secret — ключ HMAC-подписи; показывается только при создании и ротации.
+	Secret      string     `gorm:"type:varchar(128)"`
+	CreatedByID *uuid.UUID `gorm:"type:uuid"`
+	IsActive    bool       `gorm:"default:true;index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches проверяет, подписана ли подписка на тип события.
+func (s WebhookSubscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery — доставка одного события одной подписке и журнал её попыток.
+// Повторная отправка создаёт новую доставку со ссылкой ReplayOfID.
+type WebhookDelivery struct {
+	ID             uuid.UUID            `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SubscriptionID uuid.UUID            `gorm:"type:uuid;uniqueIndex:idx_webhook_deliveries_event,where:replay_of_id IS NULL;index"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	EventID        uuid.UUID            `gorm:"type:uuid;uniqueIndex:idx_webhook_deliveries_event,where:replay_of_id IS NULL"`
+	EventType      string               `gorm:"type:varchar(64)"`
+	Payload        string               `gorm:"type:jsonb"`
+	ReplayOfID     *uuid.UUID           `gorm:"type:uuid"`
+	Status         string               `gorm:"type:varchar(32);index"`
+	Attempts       int                  `gorm:"type:int;default:0"`
+	NextAttemptAt  time.Time            `gorm:"index"`
+	LastStatusCode int                  `gorm:"type:int"`
+	LastError      string               `gorm:"type:text"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
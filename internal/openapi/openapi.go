@@ -0,0 +1,38 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+// JSON возвращает спецификацию OpenAPI в формате JSON.
+func JSON() ([]byte, error) {
+	specOnce.Do(func() {
+		specJSON, specErr = yaml.YAMLToJSON(specYAML)
+	})
+	return specJSON, specErr
+}
+
+// Handler отдаёт спецификацию OpenAPI по адресу /openapi.json.
+func Handler(c *gin.Context) {
+	spec, err := JSON()
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+}
@@ -0,0 +1,169 @@
+package pass
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/logging"
+)
+
+// tokenPrefix обозначает версию формата пропуска.
+const tokenPrefix = "SP1"
+
+// Ошибки проверки пропуска.
+var (
+	ErrMalformed = errors.New("malformed pass token")
+	ErrSignature = errors.New("invalid pass signature")
+	ErrExpired   = errors.New("pass expired")
+)
+
+// Claims — содержимое пропуска водителя и техники на полигон.
+type Claims struct {
+	PassID       uuid.UUID `json:"pid"`
+	DriverID     uuid.UUID `json:"did"`
+	VehicleID    uuid.UUID `json:"vid"`
+	ContractorID uuid.UUID `json:"cid"`
+	DriverName   string    `json:"dn"`
+	Plate        string    `json:"pl"`
+	IssuedAt     int64     `json:"iat"`
+	ExpiresAt    int64     `json:"exp"`
+	KeyID        string    `json:"kid"`
+}
+
+// Signer подписывает и проверяет пропуска ключом Ed25519 сервиса.
+type Signer struct {
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+	keyID   string
+	// Ephemeral — ключ сгенерирован при старте; пропуска не переживут перезапуск.
+	Ephemeral bool
+}
+
+// Default — ключ подписи пропусков, инициализируемый при старте сервиса.
+var Default *Signer
+
+// NewSigner создаёт подписчика из 32-байтового seed ключа Ed25519.
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("pass signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	private := ed25519.NewKeyFromSeed(seed)
+	public := private.Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(public)
+
+	return &Signer{
+		private: private,
+		public:  public,
+		keyID:   hex.EncodeToString(sum[:8]),
+	}, nil
+}
+
+// Init загружает ключ подписи (seed в base64). Без ключа временный ключ
+// генерируется только при allowEphemeral (APP_ENV=development), иначе запуск прерывается.
+func Init(encoded string, allowEphemeral bool) {
+	if encoded == "" {
+		if !allowEphemeral {
+			logging.Fatal("PASS_SIGNING_KEY не задан: временный ключ допустим только при APP_ENV=development")
+		}
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			logging.Fatal("не удалось сгенерировать ключ подписи пропусков", "error", err)
+		}
+		signer, err := NewSigner(seed)
+		if err != nil {
+			logging.Fatal("не удалось создать ключ подписи пропусков", "error", err)
+		}
+		signer.Ephemeral = true
+		Default = signer
+		slog.Warn("PASS_SIGNING_KEY не задан, используется временный ключ", "key_id", signer.KeyID())
+		return
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		logging.Fatal("PASS_SIGNING_KEY должен быть в base64", "error", err)
+	}
+	signer, err := NewSigner(seed)
+	if err != nil {
+		logging.Fatal("некорректный PASS_SIGNING_KEY", "error", err)
+	}
+	Default = signer
+}
+
+// Ready проверяет, что ключ подписи пропусков инициализирован.
+func Ready() error {
+	if Default == nil {
+		return errors.New("pass signing key is not initialized")
+	}
+	return nil
+}
+
+// KeyID — короткий идентификатор открытого ключа для выбора ключа при проверке.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey возвращает открытый ключ для офлайн-проверки пропусков.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.public
+}
+
+// Sign заполняет идентификатор ключа и возвращает подписанный пропуск вида
+// SP1.<payload>.<signature> в base64url без выравнивания.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	claims.KeyID = s.keyID
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := tokenPrefix + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(s.private, []byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify проверяет подпись и срок действия пропуска на момент now.
+func (s *Signer) Verify(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 || parts[0] != tokenPrefix {
+		return Claims{}, ErrMalformed
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if !ed25519.Verify(s.public, []byte(parts[0]+"."+parts[1]), signature) {
+		return Claims{}, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrExpired
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,99 @@
+package pass
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testSigner(t *testing.T, fill byte) *Signer {
+	t.Helper()
+	signer, err := NewSigner(bytes.Repeat([]byte{fill}, ed25519.SeedSize))
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return signer
+}
+
+func TestSignVerify(t *testing.T) {
+	signer := testSigner(t, 1)
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{
+		PassID:       uuid.New(),
+		DriverID:     uuid.New(),
+		VehicleID:    uuid.New(),
+		ContractorID: uuid.New(),
+		DriverName:   "Иванов Иван",
+		Plate:        "123ABC02",
+		IssuedAt:     now.Unix(),
+		ExpiresAt:    now.Add(time.Hour).Unix(),
+	}
+
+	token, err := signer.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	got, err := signer.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	claims.KeyID = signer.KeyID()
+	if got != claims {
+		t.Errorf("claims = %+v, want %+v", got, claims)
+	}
+
+	parts := strings.Split(token, ".")
+	forged := claims
+	forged.Plate = "777ZZZ02"
+	raw, err := json.Marshal(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+
+	cases := []struct {
+		name   string
+		signer *Signer
+		token  string
+		now    time.Time
+		want   error
+	}{
+		{"tampered payload", signer, parts[0] + "." + payload + "." + parts[2], now, ErrSignature},
+		{"tampered signature", signer, parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(make([]byte, ed25519.SignatureSize)), now, ErrSignature},
+		{"other key", testSigner(t, 2), token, now, ErrSignature},
+		{"unknown prefix", signer, "SP2." + parts[1] + "." + parts[2], now, ErrMalformed},
+		{"not a token", signer, "garbage", now, ErrMalformed},
+		{"last valid second", signer, token, now.Add(time.Hour - time.Second), nil},
+		{"expired", signer, token, now.Add(time.Hour), ErrExpired},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.signer.Verify(tc.token, tc.now); !errors.Is(err, tc.want) {
+				t.Errorf("Verify error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReady(t *testing.T) {
+	defer func(signer *Signer) { Default = signer }(Default)
+
+	Default = nil
+	if err := Ready(); err == nil {
+		t.Error("Ready() = nil before the key is initialized")
+	}
+
+	Default = testSigner(t, 1)
+	if err := Ready(); err != nil {
+		t.Errorf("Ready() = %v after the key is initialized", err)
+	}
+}
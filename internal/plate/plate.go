@@ -0,0 +1,125 @@
+package plate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Kind — формат государственного номера.
+type Kind string
+
+// Форматы номеров Казахстана (стандарт 2012 года).
+const (
+	// KindPrivate — номер физического лица: 3 цифры, 3 буквы, код региона (123 ABC 02).
+	KindPrivate Kind = "PRIVATE"
+	// KindLegalEntity — номер юридического лица: 3 цифры, 2 буквы, код региона (123 AB 02).
+	KindLegalEntity Kind = "LEGAL_ENTITY"
+)
+
+// ErrInvalid возвращается, если строка не является номером Казахстана.
+var ErrInvalid = errors.New("invalid plate number")
+
+// ErrUnknownRegion возвращается, если код региона не существует.
+var ErrUnknownRegion = errors.New("unknown plate region code")
+
+// Regions сопоставляет коды регионов на номерах с названиями регионов.
+var Regions = map[string]string{
+	"01": "Астана",
+	"02": "Алматы",
+	"03": "Акмолинская область",
+	"04": "Актюбинская область",
+	"05": "Алматинская область",
+	"06": "Атырауская область",
+	"07": "Западно-Казахстанская область",
+	"08": "Жамбылская область",
+	"09": "Карагандинская область",
+	"10": "Костанайская область",
+	"11": "Кызылординская область",
+	"12": "Мангистауская область",
+	"13": "Туркестанская область",
+	"14": "Павлодарская область",
+	"15": "Северо-Казахстанская область",
+	"16": "Восточно-Казахстанская область",
+	"17": "Шымкент",
+	"18": "Абайская область",
+	"19": "Жетысуская область",
+	"20": "Улытауская область",
+}
+
+// Plate — разобранный государственный номер.
+type Plate struct {
+	// Canonical — форма для хранения и поиска: без пробелов, латиница в верхнем регистре.
+	Canonical string
+	// Display — форма для отображения с пробелами между группами.
+	Display string
+	Kind    Kind
+	Region  string
+}
+
+// RegionName возвращает название региона номера.
+func (p Plate) RegionName() string {
+	return Regions[p.Region]
+}
+
+var (
+	privatePattern     = regexp.MustCompile(`^(\d{3})([A-Z]{3})(\d{2})$`)
+	legalEntityPattern = regexp.MustCompile(`^(\d{3})([A-Z]{2})(\d{2})$`)
+)
+
+// Кириллические буквы, совпадающие по начертанию с латинскими. Пользователи часто
+// набирают номер в русской раскладке.
+var cyrillicLookalikes = strings.NewReplacer(
+	"А", "A", "В", "B", "Е", "E", "К", "K", "М", "M", "Н", "H",
+	"О", "O", "Р", "P", "С", "C", "Т", "T", "У", "Y", "Х", "X",
+)
+
+// Normalize приводит ввод пользователя к канонической записи без проверки формата:
+// убирает пробелы и дефисы, переводит в верхний регистр и заменяет кириллицу латиницей.
+func Normalize(raw string) string {
+	upper := strings.ToUpper(strings.TrimSpace(raw))
+	upper = cyrillicLookalikes.Replace(upper)
+
+	var b strings.Builder
+	for _, r := range upper {
+		switch r {
+		case ' ', '-', '\t', '_', '.':
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Parse разбирает номер в любом написании и проверяет формат и код региона.
+func Parse(raw string) (Plate, error) {
+	canonical := Normalize(raw)
+
+	var (
+		kind  Kind
+		parts []string
+	)
+	switch {
+	case privatePattern.MatchString(canonical):
+		kind = KindPrivate
+		parts = privatePattern.FindStringSubmatch(canonical)
+	case legalEntityPattern.MatchString(canonical):
+		kind = KindLegalEntity
+		parts = legalEntityPattern.FindStringSubmatch(canonical)
+	default:
+		return Plate{}, ErrInvalid
+	}
+
+	region := parts[3]
+	if _, ok := Regions[region]; !ok {
+		return Plate{}, ErrUnknownRegion
+	}
+
+	return Plate{
+		Canonical: canonical,
+		Display:   parts[1] + " " + parts[2] + " " + region,
+		Kind:      kind,
+		Region:    region,
+	}, nil
+}
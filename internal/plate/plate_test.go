@@ -0,0 +1,97 @@
+package plate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name      string
+		raw       string
+		canonical string
+		display   string
+		kind      Kind
+		region    string
+	}{
+		{"private", "123ABC02", "123ABC02", "123 ABC 02", KindPrivate, "02"},
+		{"private with spaces", "123 ABC 02", "123ABC02", "123 ABC 02", KindPrivate, "02"},
+		{"legal entity", "777 AB 01", "777AB01", "777 AB 01", KindLegalEntity, "01"},
+		{"lower case and separators", " 045-kz_x.17 ", "045KZX17", "045 KZX 17", KindPrivate, "17"},
+		{"cyrillic lookalikes", "123 АВС 02", "123ABC02", "123 ABC 02", KindPrivate, "02"},
+		{"lower case cyrillic", "500 мн 05", "500MH05", "500 MH 05", KindLegalEntity, "05"},
+		{"all cyrillic lookalikes", "001 АВЕ 09", "001ABE09", "001 ABE 09", KindPrivate, "09"},
+		{"last region", "321 OPT 20", "321OPT20", "321 OPT 20", KindPrivate, "20"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.raw, err)
+			}
+			want := Plate{Canonical: tc.canonical, Display: tc.display, Kind: tc.kind, Region: tc.region}
+			if got != want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tc.raw, got, want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"empty", "", ErrInvalid},
+		{"digits only", "12345678", ErrInvalid},
+		{"one letter", "123 A 02", ErrInvalid},
+		{"four letters", "123 ABCD 02", ErrInvalid},
+		{"two digits", "12 ABC 02", ErrInvalid},
+		{"region with one digit", "123 ABC 2", ErrInvalid},
+		{"non-lookalike cyrillic", "123 ЖЗИ 02", ErrInvalid},
+		{"old format", "A 123 BCD", ErrInvalid},
+		{"region zero", "123 ABC 00", ErrUnknownRegion},
+		{"region above range", "123 AB 21", ErrUnknownRegion},
+		{"region 99", "123 ABC 99", ErrUnknownRegion},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.raw); !errors.Is(err, tc.want) {
+				t.Errorf("Parse(%q) error = %v, want %v", tc.raw, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegionName(t *testing.T) {
+	for code := range Regions {
+		p, err := Parse("123 ABC " + code)
+		if err != nil {
+			t.Fatalf("Parse with region %s: %v", code, err)
+		}
+		if p.RegionName() != Regions[code] {
+			t.Errorf("RegionName() = %q, want %q", p.RegionName(), Regions[code])
+		}
+	}
+	if name := (Plate{Region: "99"}).RegionName(); name != "" {
+		t.Errorf("RegionName() for unknown region = %q, want empty", name)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"123 abc 02":  "123ABC02",
+		"123-АВС-02":  "123ABC02",
+		"\t777ab01 ":  "777AB01",
+		"кмнорстух":   "KMHOPCTYX",
+		"not a plate": "NOTAPLATE",
+	}
+	for raw, want := range cases {
+		if got := Normalize(raw); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
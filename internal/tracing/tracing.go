@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// ServiceName — имя сервиса в трассировке по умолчанию; переопределяется OTEL_SERVICE_NAME.
+const ServiceName = "snowops-roles"
+
+// Атрибуты спанов с данными текущего пользователя.
+const (
+	AttrUserID = attribute.Key("enduser.id")
+	AttrRole   = attribute.Key("enduser.role")
+	AttrOrgID  = attribute.Key("snowops.org_id")
+)
+
+var tracer = otel.Tracer("github.com/MSTimX/Snowops-roles")
+
+// Init настраивает трассировку с указанным экспортёром:
+// none (по умолчанию) — спаны не экспортируются, но traceparent передаётся дальше;
+// stdout — спаны пишутся в stdout для локальной отладки;
+// otlp — экспорт по OTLP/HTTP, адрес и заголовки задаются стандартными
+// переменными OTEL_EXPORTER_OTLP_*.
+// Возвращает функцию, которая дописывает накопленные спаны при остановке.
+func Init(ctx context.Context, exporterName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported traces exporter %q", exporterName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("трассировка включена", "exporter", exporterName)
+
+	return provider.Shutdown, nil
+}
+
+// untracedPaths — служебные маршруты, которые опрашиваются постоянно и не трассируются.
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// Middleware создаёт серверный спан на каждый запрос, принимая входящий traceparent.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
+
+// InstrumentDB добавляет спаны для запросов GORM. Значения параметров запросов
+// в спаны не попадают: среди них телефоны и ИИН.
+func InstrumentDB(db *gorm.DB) error {
+	return db.Use(otelgorm.NewPlugin(
+		otelgorm.WithoutQueryVariables(),
+		otelgorm.WithoutMetrics(),
+	))
+}
+
+// StartAuth открывает спан проверки аутентификации. Контекст запроса не
+// меняется: спаны обработчика остаются дочерними к серверному спану.
+func StartAuth(c *gin.Context, name string) trace.Span {
+	_, span := tracer.Start(c.Request.Context(), name)
+	return span
+}
+
+// EndAuth завершает спан аутентификации; при отказе отмечает его ошибкой.
+func EndAuth(c *gin.Context, span trace.Span) {
+	if c.IsAborted() {
+		span.SetStatus(codes.Error, "authentication failed")
+	}
+	span.End()
+}
+
+// SetPrincipal записывает пользователя, роль и организацию в спан
+// аутентификации и в серверный спан запроса.
+func SetPrincipal(c *gin.Context, span trace.Span, userID, role, orgID string) {
+	attrs := []attribute.KeyValue{
+		AttrUserID.String(userID),
+		AttrRole.String(role),
+		AttrOrgID.String(orgID),
+	}
+	span.SetAttributes(attrs...)
+	trace.SpanFromContext(c.Request.Context()).SetAttributes(attrs...)
+}
@@ -0,0 +1,359 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+// Заголовки исходящего запроса.
+const (
+	HeaderEvent     = "X-SnowOps-Event"
+	HeaderDelivery  = "X-SnowOps-Delivery"
+	HeaderTimestamp = "X-SnowOps-Timestamp"
+	HeaderSignature = "X-SnowOps-Signature"
+)
+
+// maxOrgDepth ограничивает подъём по иерархии организаций (акимат → ТОО → подрядчик).
+const maxOrgDepth = 5
+
+// maxErrorLength ограничивает текст последней ошибки доставки.
+const maxErrorLength = 1024
+
+// GenerateSecret создаёт новый ключ подписи для подписки.
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign вычисляет подпись запроса: HMAC-SHA256 от "<timestamp>.<body>" в hex
+// с префиксом "sha256=". Получатель проверяет её тем же ключом и отклоняет
+// запросы со слишком старой меткой времени.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// FanOut — Publisher для диспетчера outbox: создаёт доставку события для каждой
+// подходящей активной подписки. Повторная публикация того же события не
+// создаёт дубликатов.
+type FanOut struct {
+	DB *gorm.DB
+}
+
+func (f FanOut) Publish(ctx context.Context, event events.Envelope) error {
+	db := f.DB.WithContext(ctx)
+
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event.Type) {
+			continue
+		}
+		if subscription.OrganizationID != nil {
+			inSubtree, err := InSubtree(db, event.OrganizationID, *subscription.OrganizationID)
+			if err != nil {
+				return err
+			}
+			if !inSubtree {
+				continue
+			}
+		}
+
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// InSubtree проверяет, что организация orgID — это rootID или её потомок.
+func InSubtree(db *gorm.DB, orgID *uuid.UUID, rootID uuid.UUID) (bool, error) {
+	current := orgID
+	for depth := 0; current != nil && depth < maxOrgDepth; depth++ {
+		if *current == rootID {
+			return true, nil
+		}
+
+		var org models.Organization
+		err := db.Select("id", "parent_org_id").Where("id = ?", *current).First(&org).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		current = org.ParentOrgID
+	}
+	return false, nil
+}
+
+// Sender отправляет ожидающие доставки подписчикам. Неуспешная доставка
+// повторяется с экспоненциальной задержкой, после MaxAttempts попыток она
+// помечается FAILED и может быть отправлена повторно вручную.
+//
+// Пачка доставок сначала захватывается короткой транзакцией: next_attempt_at
+// сдвигается на Lease, и другие экземпляры её не берут. Запросы подписчикам
+// выполняются вне транзакции, результат каждой попытки записывается отдельно.
+// Если экземпляр остановится, не записав результат, доставка будет отправлена
+// повторно по истечении Lease.
+type Sender struct {
+	db     *gorm.DB
+	client *http.Client
+
+	BatchSize    int
+	PollInterval time.Duration
+	Lease        time.Duration
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	MaxAttempts  int
+}
+
+// NewSender создаёт отправителя с параметрами по умолчанию.
+func NewSender(db *gorm.DB) *Sender {
+	return &Sender{
+		db:           db,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		BatchSize:    50,
+		PollInterval: 2 * time.Second,
+		Lease:        15 * time.Minute,
+		MinBackoff:   10 * time.Second,
+		MaxBackoff:   time.Hour,
+		MaxAttempts:  12,
+	}
+}
+
+// Run отправляет доставки до отмены ctx.
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := s.SendOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("ошибка отправки webhook", "error", err)
+		}
+
+		if err == nil && processed == s.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendOnce отправляет одну пачку доставок и возвращает их количество.
+func (s *Sender) SendOnce(ctx context.Context) (int, error) {
+	batch, leaseUntil, err := s.claim(ctx)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+
+	subscriptionIDs := make([]uuid.UUID, 0, len(batch))
+	for _, delivery := range batch {
+		subscriptionIDs = append(subscriptionIDs, delivery.SubscriptionID)
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.WithContext(ctx).Where("id IN ?", subscriptionIDs).Find(&subscriptions).Error; err != nil {
+		return 0, err
+	}
+	byID := make(map[uuid.UUID]models.WebhookSubscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		byID[subscription.ID] = subscription
+	}
+
+	processed := 0
+	for _, delivery := range batch {
+		if ctx.Err() != nil {
+			// Незавершённые доставки вернутся в работу по истечении аренды.
+			return processed, ctx.Err()
+		}
+		processed++
+
+		updates := s.attempt(ctx, byID[delivery.SubscriptionID], delivery)
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+		if err := s.record(ctx, delivery.ID, leaseUntil, updates); err != nil {
+			return processed, err
+		}
+	}
+
+	return processed, nil
+}
+
+// claim захватывает пачку ожидающих доставок до leaseUntil и фиксирует захват.
+func (s *Sender) claim(ctx context.Context) ([]models.WebhookDelivery, time.Time, error) {
+	// Postgres хранит время с точностью до микросекунд; значение служит меткой аренды.
+	now := time.Now()
+	leaseUntil := now.Add(s.Lease).Truncate(time.Microsecond)
+
+	var batch []models.WebhookDelivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("created_at").
+			Limit(s.BatchSize).
+			Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(batch))
+		for _, delivery := range batch {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return batch, leaseUntil, nil
+}
+
+// record сохраняет результат попытки, если доставка всё ещё захвачена этим
+// отправителем: после истечения аренды её мог взять другой экземпляр.
+func (s *Sender) record(ctx context.Context, deliveryID uuid.UUID, leaseUntil time.Time, updates map[string]interface{}) error {
+	result := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", deliveryID, models.WebhookDeliveryPending, leaseUntil).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		slog.Warn("результат доставки webhook не записан: аренда истекла", "delivery_id", deliveryID)
+	}
+	return nil
+}
+
+// attempt выполняет одну попытку доставки и возвращает изменения записи доставки.
+func (s *Sender) attempt(ctx context.Context, subscription models.WebhookSubscription, delivery models.WebhookDelivery) map[string]interface{} {
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+
+	// Подписка удалена или отключена — доставка не выполняется.
+	if !subscription.IsActive {
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = "subscription is inactive"
+		return updates
+	}
+
+	statusCode, err := s.post(ctx, subscription, delivery)
+	updates["last_status_code"] = statusCode
+	if err == nil {
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = time.Now()
+		updates["last_error"] = ""
+		return updates
+	}
+
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	updates["last_error"] = message
+
+	if attempts >= s.MaxAttempts {
+		updates["status"] = models.WebhookDeliveryFailed
+		slog.Warn("доставка webhook не удалась, попытки исчерпаны", "delivery_id", delivery.ID, "subscription_id", subscription.ID, "attempts", attempts, "error", err)
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(s.backoff(attempts))
+	}
+
+	return updates
+}
+
+// post отправляет подписанный запрос; успешным считается любой ответ 2xx.
+func (s *Sender) post(ctx context.Context, subscription models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SnowOps-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff возвращает экспоненциально растущую задержку перед следующей попыткой.
+func (s *Sender) backoff(attempts int) time.Duration {
+	delay := s.MinBackoff
+	for i := 1; i < attempts && delay < s.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.MaxBackoff {
+		delay = s.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,196 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/MSTimX/Snowops-roles/internal/events"
+	"github.com/MSTimX/Snowops-roles/internal/models"
+)
+
+const testSecret = "whsec_test"
+
+func testDelivery(attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:        uuid.New(),
+		EventType: events.DriverCreated,
+		Payload:   `{"type":"` + events.DriverCreated + `"}`,
+		Status:    models.WebhookDeliveryPending,
+		Attempts:  attempts,
+	}
+}
+
+// subscriber поднимает подписчика, отвечающего status, и считает запросы.
+func subscriber(t *testing.T, status int, check func(*http.Request, []byte)) (models.WebhookSubscription, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		if check != nil {
+			check(r, body)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return models.WebhookSubscription{ID: uuid.New(), URL: server.URL, Secret: testSecret, IsActive: true}, &calls
+}
+
+func TestAttemptSignsRequest(t *testing.T) {
+	delivery := testDelivery(0)
+	subscription, calls := subscriber(t, http.StatusNoContent, func(r *http.Request, body []byte) {
+		if got := r.Header.Get(HeaderEvent); got != delivery.EventType {
+			t.Errorf("%s = %q, want %q", HeaderEvent, got, delivery.EventType)
+		}
+		if got := r.Header.Get(HeaderDelivery); got != delivery.ID.String() {
+			t.Errorf("%s = %q, want %q", HeaderDelivery, got, delivery.ID)
+		}
+		if string(body) != delivery.Payload {
+			t.Errorf("body = %s, want %s", body, delivery.Payload)
+		}
+
+		timestamp := r.Header.Get(HeaderTimestamp)
+		if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+			t.Errorf("%s = %q: %v", HeaderTimestamp, timestamp, err)
+		}
+		mac := hmac.New(sha256.New, []byte(testSecret))
+		mac.Write([]byte(timestamp + "." + string(body)))
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if got := r.Header.Get(HeaderSignature); got != want {
+			t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+		}
+	})
+
+	updates := NewSender(nil).attempt(context.Background(), subscription, delivery)
+
+	if calls.Load() != 1 {
+		t.Fatalf("requests = %d, want 1", calls.Load())
+	}
+	if updates["status"] != models.WebhookDeliveryDelivered {
+		t.Errorf("status = %v, want %s", updates["status"], models.WebhookDeliveryDelivered)
+	}
+	if updates["attempts"] != 1 || updates["last_status_code"] != http.StatusNoContent {
+		t.Errorf("updates = %v", updates)
+	}
+}
+
+func TestAttemptSchedulesRetry(t *testing.T) {
+	subscription, _ := subscriber(t, http.StatusInternalServerError, nil)
+	sender := NewSender(nil)
+
+	before := time.Now()
+	updates := sender.attempt(context.Background(), subscription, testDelivery(2))
+
+	if _, ok := updates["status"]; ok {
+		t.Errorf("status = %v, want delivery to stay pending", updates["status"])
+	}
+	if updates["attempts"] != 3 || updates["last_status_code"] != http.StatusInternalServerError {
+		t.Errorf("updates = %v", updates)
+	}
+	next, ok := updates["next_attempt_at"].(time.Time)
+	if !ok {
+		t.Fatalf("next_attempt_at not set: %v", updates)
+	}
+	if delay := next.Sub(before); delay < 40*time.Second || delay > 41*time.Second {
+		t.Errorf("retry delay = %v, want 40s", delay)
+	}
+}
+
+func TestAttemptMarksFailed(t *testing.T) {
+	sender := NewSender(nil)
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		subscription, _ := subscriber(t, http.StatusBadGateway, nil)
+		updates := sender.attempt(context.Background(), subscription, testDelivery(sender.MaxAttempts-1))
+
+		if updates["status"] != models.WebhookDeliveryFailed {
+			t.Errorf("status = %v, want %s", updates["status"], models.WebhookDeliveryFailed)
+		}
+		if _, ok := updates["next_attempt_at"]; ok {
+			t.Errorf("next_attempt_at = %v, want no retry", updates["next_attempt_at"])
+		}
+		if updates["last_error"] != "unexpected response status 502" {
+			t.Errorf("last_error = %v", updates["last_error"])
+		}
+	})
+
+	t.Run("inactive subscription", func(t *testing.T) {
+		subscription, calls := subscriber(t, http.StatusOK, nil)
+		subscription.IsActive = false
+		updates := sender.attempt(context.Background(), subscription, testDelivery(0))
+
+		if calls.Load() != 0 {
+			t.Errorf("requests = %d, want none", calls.Load())
+		}
+		if updates["status"] != models.WebhookDeliveryFailed {
+			t.Errorf("status = %v, want %s", updates["status"], models.WebhookDeliveryFailed)
+		}
+	})
+
+	t.Run("unreachable subscriber", func(t *testing.T) {
+		subscription, _ := subscriber(t, http.StatusOK, nil)
+		subscription.URL = "http://127.0.0.1:1"
+		updates := sender.attempt(context.Background(), subscription, testDelivery(0))
+
+		if _, ok := updates["next_attempt_at"]; !ok || updates["last_status_code"] != 0 {
+			t.Errorf("updates = %v, want a retry without status code", updates)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	sender := NewSender(nil)
+
+	for attempts, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		5:  160 * time.Second,
+		9:  2560 * time.Second,
+		10: time.Hour,
+		50: time.Hour,
+	} {
+		if got := sender.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// TestSubscriptionMatchesEventTypes проверяет фильтр подписки на типах событий,
+// которые действительно публикует сервис.
+func TestSubscriptionMatchesEventTypes(t *testing.T) {
+	all := models.WebhookSubscription{}
+	drivers := models.WebhookSubscription{EventTypes: []string{events.DriverCreated, events.DriverDeactivated}}
+
+	for _, eventType := range events.Types {
+		if !all.Matches(eventType) {
+			t.Errorf("subscription without filter does not match %s", eventType)
+		}
+		want := eventType == events.DriverCreated || eventType == events.DriverDeactivated
+		if got := drivers.Matches(eventType); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", eventType, got, want)
+		}
+	}
+
+	// Имена событий сравниваются точно.
+	for _, eventType := range []string{"driver.created", "drivercreated", ""} {
+		if drivers.Matches(eventType) {
+			t.Errorf("Matches(%q) = true, want false", eventType)
+		}
+	}
+}